@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -11,6 +13,7 @@ type Input struct {
 	actor                              string
 	workdir                            string
 	workflowsPath                      string
+	workflowsDir                       string
 	autodetectEvent                    bool
 	eventPath                          string
 	reuseContainers                    bool
@@ -24,17 +27,24 @@ type Input struct {
 	forcePull                          bool
 	forceRebuild                       bool
 	noOutput                           bool
+	showTimestamps                     bool
 	envfile                            string
 	inputfile                          string
 	secretfile                         string
 	varfile                            string
 	insecureSecrets                    bool
+	maskedEnv                          []string
+	secretEnvPrefix                    string
 	defaultBranch                      string
 	privileged                         bool
 	usernsMode                         string
 	containerArchitecture              string
 	containerDaemonSocket              string
 	containerOptions                   string
+	containerNamePrefix                string
+	uniqueContainerNames               bool
+	nonStrictBash                      bool
+	containerBuildArgs                 []string
 	noWorkflowRecurse                  bool
 	useGitIgnore                       bool
 	githubInstance                     string
@@ -42,6 +52,7 @@ type Input struct {
 	containerCapDrop                   []string
 	autoRemove                         bool
 	artifactServerPath                 string
+	artifactPath                       string
 	artifactServerAddr                 string
 	artifactServerPort                 string
 	noCacheServer                      bool
@@ -57,9 +68,14 @@ type Input struct {
 	actionCachePath                    string
 	actionOfflineMode                  bool
 	logPrefixJobID                     bool
+	jobLogLevels                       []string
 	networkName                        string
 	useNewActionCache                  bool
 	localRepository                    []string
+	randomizeFileCommands              bool
+	registryMirrors                    []string
+	strictWorkflowCommands             bool
+	shellInterpreters                  []string
 }
 
 func (i *Input) resolve(path string) string {
@@ -90,13 +106,38 @@ func (i *Input) Varfile() string {
 	return i.resolve(i.varfile)
 }
 
+// newJobLogLevels parses the job-log-level flags of the form job=level
+func (i *Input) newJobLogLevels() (map[string]log.Level, error) {
+	levels := map[string]log.Level{}
+	for _, l := range i.jobLogLevels {
+		job, level, ok := strings.Cut(l, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid job log level '%s', expected job=level", l)
+		}
+		lvl, err := log.ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid job log level '%s': %w", l, err)
+		}
+		levels[job] = lvl
+	}
+	return levels, nil
+}
+
 // Workdir returns path to workdir
 func (i *Input) Workdir() string {
 	return i.resolve(".")
 }
 
+// WorkflowsDir returns path to the repository holding the workflows, empty when it is the workdir
+func (i *Input) WorkflowsDir() string {
+	return i.resolve(i.workflowsDir)
+}
+
 // WorkflowsPath returns path to workflow file(s)
 func (i *Input) WorkflowsPath() string {
+	if i.workflowsDir != "" && !filepath.IsAbs(i.workflowsPath) {
+		return filepath.Join(i.WorkflowsDir(), i.workflowsPath)
+	}
 	return i.resolve(i.workflowsPath)
 }
 
@@ -20,3 +20,36 @@ func (i *Input) newPlatforms() map[string]string {
 	}
 	return platforms
 }
+
+func (i *Input) newContainerBuildArgs() map[string]string {
+	buildArgs := map[string]string{}
+	for _, a := range i.containerBuildArgs {
+		aParts := strings.SplitN(a, "=", 2)
+		if len(aParts) == 2 {
+			buildArgs[aParts[0]] = aParts[1]
+		}
+	}
+	return buildArgs
+}
+
+func (i *Input) newShellInterpreters() map[string]string {
+	interpreters := map[string]string{}
+	for _, s := range i.shellInterpreters {
+		sParts := strings.SplitN(s, "=", 2)
+		if len(sParts) == 2 {
+			interpreters[sParts[0]] = sParts[1]
+		}
+	}
+	return interpreters
+}
+
+func (i *Input) newRegistryMirrors() map[string]string {
+	mirrors := map[string]string{}
+	for _, m := range i.registryMirrors {
+		mParts := strings.SplitN(m, "=", 2)
+		if len(mParts) == 2 {
+			mirrors[mParts[0]] = mParts[1]
+		}
+	}
+	return mirrors
+}
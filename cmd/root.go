@@ -15,11 +15,9 @@ import (
 	"github.com/adrg/xdg"
 	"github.com/andreaskoch/go-fswatch"
 	docker_container "github.com/docker/docker/api/types/container"
-	"github.com/joho/godotenv"
 	gitignore "github.com/sabhiram/go-gitignore"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 
 	"github.com/nektos/act/pkg/artifactcache"
 	"github.com/nektos/act/pkg/artifacts"
@@ -53,7 +51,7 @@ func Execute(ctx context.Context, version string) {
 	rootCmd.Flags().StringArrayVar(&input.vars, "var", []string{}, "variable to make available to actions with optional value (e.g. --var myvar=foo or --var myvar)")
 	rootCmd.Flags().StringArrayVarP(&input.envs, "env", "", []string{}, "env to make available to actions with optional value (e.g. --env myenv=foo or --env myenv)")
 	rootCmd.Flags().StringArrayVarP(&input.inputs, "input", "", []string{}, "action input to make available to actions (e.g. --input myinput=foo)")
-	rootCmd.Flags().StringArrayVarP(&input.platforms, "platform", "P", []string{}, "custom image to use per platform (e.g. -P ubuntu-18.04=nektos/act-environments-ubuntu:18.04)")
+	rootCmd.Flags().StringArrayVarP(&input.platforms, "platform", "P", []string{}, "custom image to use per platform (e.g. -P ubuntu-18.04=nektos/act-environments-ubuntu:18.04), labels joined by '+' (e.g. -P self-hosted+gpu=my-gpu-image) take precedence when runs-on contains all of them")
 	rootCmd.Flags().BoolVarP(&input.reuseContainers, "reuse", "r", false, "don't remove container(s) on successfully completed workflow(s) to maintain state between runs")
 	rootCmd.Flags().BoolVarP(&input.bindWorkdir, "bind", "b", false, "bind working directory to container, rather than copy")
 	rootCmd.Flags().BoolVarP(&input.forcePull, "pull", "p", true, "pull docker image(s) even if already present")
@@ -77,18 +75,27 @@ func Execute(ctx context.Context, version string) {
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolVar(&input.jsonLogger, "json", false, "Output logs in json format")
 	rootCmd.PersistentFlags().BoolVar(&input.logPrefixJobID, "log-prefix-job-id", false, "Output the job id within non-json logs instead of the entire name")
+	rootCmd.PersistentFlags().StringArrayVarP(&input.jobLogLevels, "job-log-level", "", []string{}, "Log level of a job by its id (e.g. build=debug), other jobs log at the global level")
 	rootCmd.PersistentFlags().BoolVarP(&input.noOutput, "quiet", "q", false, "disable logging of output from steps")
+	rootCmd.PersistentFlags().BoolVarP(&input.showTimestamps, "show-timestamps", "", false, "prefix each line of the output of steps with its timestamp")
 	rootCmd.PersistentFlags().BoolVarP(&input.dryrun, "dryrun", "n", false, "disable container creation, validates only workflow correctness")
 	rootCmd.PersistentFlags().StringVarP(&input.secretfile, "secret-file", "", ".secrets", "file with list of secrets to read from (e.g. --secret-file .secrets)")
 	rootCmd.PersistentFlags().StringVarP(&input.varfile, "var-file", "", ".vars", "file with list of vars to read from (e.g. --var-file .vars)")
 	rootCmd.PersistentFlags().BoolVarP(&input.insecureSecrets, "insecure-secrets", "", false, "NOT RECOMMENDED! Doesn't hide secrets while printing logs.")
+	rootCmd.PersistentFlags().StringArrayVarP(&input.maskedEnv, "mask-env", "", []string{}, "name of an env var whose value is hidden in logs like a secret")
+	rootCmd.PersistentFlags().StringVarP(&input.secretEnvPrefix, "secret-env-prefix", "", "", "read env vars with this prefix as secrets without it (e.g. --secret-env-prefix ACT_SECRET_ makes ACT_SECRET_FOO the secret FOO)")
 	rootCmd.PersistentFlags().StringVarP(&input.envfile, "env-file", "", ".env", "environment file to read and use as env in the containers")
 	rootCmd.PersistentFlags().StringVarP(&input.inputfile, "input-file", "", ".input", "input file to read and use as action input")
 	rootCmd.PersistentFlags().StringVarP(&input.containerArchitecture, "container-architecture", "", "", "Architecture which should be used to run containers, e.g.: linux/amd64. If not specified, will use host default architecture. Requires Docker server API Version 1.41+. Ignored on earlier Docker server platforms.")
 	rootCmd.PersistentFlags().StringVarP(&input.containerDaemonSocket, "container-daemon-socket", "", "", "URI to Docker Engine socket (e.g.: unix://~/.docker/run/docker.sock or - to disable bind mounting the socket)")
 	rootCmd.PersistentFlags().StringVarP(&input.containerOptions, "container-options", "", "", "Custom docker container options for the job container without an options property in the job definition")
+	rootCmd.PersistentFlags().BoolVarP(&input.nonStrictBash, "non-strict-bash", "", false, "run `shell: bash` steps without `-e -o pipefail`, for legacy scripts")
+	rootCmd.PersistentFlags().StringVarP(&input.containerNamePrefix, "container-name-prefix", "", "", "prefix of the names of containers, volumes and networks (default \"act\")")
+	rootCmd.PersistentFlags().BoolVarP(&input.uniqueContainerNames, "unique-container-names", "", false, "add a random id of the run to container names, so concurrent runs on one host don't collide (ignored with --reuse)")
+	rootCmd.PersistentFlags().StringArrayVarP(&input.containerBuildArgs, "container-build-arg", "", []string{}, "Build arg passed when building the image of a Dockerfile action (e.g. HTTP_PROXY=http://proxy:3128)")
 	rootCmd.PersistentFlags().StringVarP(&input.githubInstance, "github-instance", "", "github.com", "GitHub instance to use. Don't use this if you are not using GitHub Enterprise Server.")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPath, "artifact-server-path", "", "", "Defines the path where the artifact server stores uploads and retrieves downloads from. If not specified the artifact server will not start.")
+	rootCmd.PersistentFlags().StringVarP(&input.artifactPath, "artifact-path", "", "", "Directory mounted into job containers as ACT_ARTIFACT_PATH to collect files the steps leave behind")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerAddr, "artifact-server-addr", "", common.GetOutboundIP().String(), "Defines the address to which the artifact server binds.")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPort, "artifact-server-port", "", "34567", "Defines the port where the artifact server listens.")
 	rootCmd.PersistentFlags().BoolVarP(&input.noSkipCheckout, "no-skip-checkout", "", false, "Do not skip actions/checkout")
@@ -101,6 +108,11 @@ func Execute(ctx context.Context, version string) {
 	rootCmd.PersistentFlags().StringVarP(&input.networkName, "network", "", "host", "Sets a docker network name. Defaults to host.")
 	rootCmd.PersistentFlags().BoolVarP(&input.useNewActionCache, "use-new-action-cache", "", false, "Enable using the new Action Cache for storing Actions locally")
 	rootCmd.PersistentFlags().StringArrayVarP(&input.localRepository, "local-repository", "", []string{}, "Replaces the specified repository and ref with a local folder (e.g. https://github.com/test/test@v0=/home/act/test or test/test@v0=/home/act/test, the latter matches any hosts or protocols)")
+	rootCmd.PersistentFlags().BoolVarP(&input.randomizeFileCommands, "randomize-file-commands", "", false, "Use random per-step file names for GITHUB_OUTPUT, GITHUB_ENV and the other file commands")
+	rootCmd.PersistentFlags().StringArrayVarP(&input.registryMirrors, "registry-mirror", "", []string{}, "Pull images matching a repository prefix from a mirror (e.g. docker.io/library=mirror.internal)")
+	rootCmd.PersistentFlags().BoolVarP(&input.strictWorkflowCommands, "strict-workflow-commands", "", false, "Fail steps printing unknown or malformed workflow commands instead of ignoring them")
+	rootCmd.PersistentFlags().StringArrayVarP(&input.shellInterpreters, "shell-interpreter", "", []string{}, "Use the interpreter at the given path for a builtin shell (e.g. bash=/usr/local/bin/bash)")
+	rootCmd.PersistentFlags().StringVarP(&input.workflowsDir, "workflows-dir", "", "", "path to the repository holding the workflows and local actions, when it differs from the working directory")
 	rootCmd.SetArgs(args())
 
 	if err := rootCmd.Execute(); err != nil {
@@ -281,26 +293,9 @@ func parseEnvs(env []string) map[string]string {
 	return envs
 }
 
-func readYamlFile(file string) (map[string]string, error) {
-	content, err := os.ReadFile(file)
-	if err != nil {
-		return nil, err
-	}
-	ret := map[string]string{}
-	if err = yaml.Unmarshal(content, &ret); err != nil {
-		return nil, err
-	}
-	return ret, nil
-}
-
 func readEnvs(path string, envs map[string]string) bool {
 	if _, err := os.Stat(path); err == nil {
-		var env map[string]string
-		if ext := filepath.Ext(path); ext == ".yml" || ext == ".yaml" {
-			env, err = readYamlFile(path)
-		} else {
-			env, err = godotenv.Read(path)
-		}
+		env, err := runner.ReadEnvFile(path)
 		if err != nil {
 			log.Fatalf("Error loading from %s: %v", path, err)
 		}
@@ -526,6 +521,11 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			log.Warnf(deprecationWarning, "container-cap-drop", fmt.Sprintf("--cap-drop=%s", input.containerCapDrop))
 		}
 
+		jobLogLevels, err := input.newJobLogLevels()
+		if err != nil {
+			return err
+		}
+
 		// run the plan
 		config := &runner.Config{
 			Actor:                              input.actor,
@@ -536,30 +536,41 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			ForceRebuild:                       input.forceRebuild,
 			ReuseContainers:                    input.reuseContainers,
 			Workdir:                            input.Workdir(),
+			WorkflowsDir:                       input.WorkflowsDir(),
 			ActionCacheDir:                     input.actionCachePath,
 			ActionOfflineMode:                  input.actionOfflineMode,
 			BindWorkdir:                        input.bindWorkdir,
+			BindWorkdirSet:                     cmd.Flags().Changed("bind"),
 			LogOutput:                          !input.noOutput,
+			ShowTimestamps:                     input.showTimestamps,
 			JSONLogger:                         input.jsonLogger,
 			LogPrefixJobID:                     input.logPrefixJobID,
+			JobLogLevels:                       jobLogLevels,
 			Env:                                envs,
 			Secrets:                            secrets,
 			Vars:                               vars,
 			Inputs:                             inputs,
 			Token:                              secrets["GITHUB_TOKEN"],
 			InsecureSecrets:                    input.insecureSecrets,
+			MaskedEnv:                          input.maskedEnv,
+			SecretEnvPrefix:                    input.secretEnvPrefix,
 			Platforms:                          input.newPlatforms(),
 			Privileged:                         input.privileged,
 			UsernsMode:                         input.usernsMode,
 			ContainerArchitecture:              input.containerArchitecture,
 			ContainerDaemonSocket:              input.containerDaemonSocket,
 			ContainerOptions:                   input.containerOptions,
+			ContainerNamePrefix:                input.containerNamePrefix,
+			NonStrictBash:                      input.nonStrictBash,
+			UniqueContainerNames:               input.uniqueContainerNames,
+			ContainerBuildArgs:                 input.newContainerBuildArgs(),
 			UseGitIgnore:                       input.useGitIgnore,
 			GitHubInstance:                     input.githubInstance,
 			ContainerCapAdd:                    input.containerCapAdd,
 			ContainerCapDrop:                   input.containerCapDrop,
 			AutoRemove:                         input.autoRemove,
 			ArtifactServerPath:                 input.artifactServerPath,
+			ArtifactPath:                       input.resolve(input.artifactPath),
 			ArtifactServerAddr:                 input.artifactServerAddr,
 			ArtifactServerPort:                 input.artifactServerPort,
 			NoSkipCheckout:                     input.noSkipCheckout,
@@ -568,6 +579,10 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			ReplaceGheActionTokenWithGithubCom: input.replaceGheActionTokenWithGithubCom,
 			Matrix:                             matrixes,
 			ContainerNetworkMode:               docker_container.NetworkMode(input.networkName),
+			RandomizeFileCommands:              input.randomizeFileCommands,
+			RegistryMirrors:                    input.newRegistryMirrors(),
+			StrictWorkflowCommands:             input.strictWorkflowCommands,
+			ShellInterpreters:                  input.newShellInterpreters(),
 		}
 		if input.useNewActionCache || len(input.localRepository) > 0 {
 			if input.actionOfflineMode {
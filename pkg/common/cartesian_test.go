@@ -37,3 +37,21 @@ func TestCartesianProduct(t *testing.T) {
 	output = CartesianProduct(input)
 	assert.Len(output, 0)
 }
+
+func TestCartesianProductOrder(t *testing.T) {
+	input := map[string][]interface{}{
+		"os":   {"ubuntu", "windows"},
+		"node": {18, 20},
+		"arch": {"x64"},
+	}
+
+	expected := []map[string]interface{}{
+		{"arch": "x64", "node": 18, "os": "ubuntu"},
+		{"arch": "x64", "node": 18, "os": "windows"},
+		{"arch": "x64", "node": 20, "os": "ubuntu"},
+		{"arch": "x64", "node": 20, "os": "windows"},
+	}
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, expected, CartesianProduct(input))
+	}
+}
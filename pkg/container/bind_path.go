@@ -0,0 +1,28 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandBindHostPath expands a leading `~` to the home directory of the user
+// and `$VAR`/`${VAR}` environment variables in the host side of a bind or
+// volume like `~/.cache:/cache`. Docker doesn't expand them itself.
+// The container side and volumes without a host side are returned unchanged.
+func ExpandBindHostPath(volume string) string {
+	source, target, ok := strings.Cut(volume, ":")
+	// a single letter is the drive of a windows path, e.g. `C:\data:/data`
+	if !ok || (len(source) == 1 && source != "~") {
+		return volume
+	}
+
+	if source == "~" || strings.HasPrefix(source, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			source = filepath.Join(home, source[1:])
+		}
+	}
+	source = os.ExpandEnv(source)
+
+	return source + ":" + target
+}
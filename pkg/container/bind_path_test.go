@@ -0,0 +1,36 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandBindHostPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	assert.NoError(t, err)
+	t.Setenv("ACT_TEST_DATA", "/srv/data")
+
+	table := []struct {
+		volume   string
+		expected string
+	}{
+		{"~/x:/x", filepath.Join(home, "x") + ":/x"},
+		{"~:/home/runner:ro", home + ":/home/runner:ro"},
+		{"$HOME/y:/y", os.Getenv("HOME") + "/y:/y"},
+		{"${ACT_TEST_DATA}/z:/z:ro", "/srv/data/z:/z:ro"},
+		{"/abs/path:/$HOME", "/abs/path:/$HOME"},
+		{"named-volume:/data", "named-volume:/data"},
+		{"/anonymous", "/anonymous"},
+		{`C:\data:/data`, `C:\data:/data`},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.volume, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExpandBindHostPath(tt.volume))
+		})
+	}
+	assert.True(t, filepath.IsAbs(ExpandBindHostPath("$HOME/y:/y")))
+}
@@ -0,0 +1,36 @@
+package container
+
+import (
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// OptionsWorkingDir returns the working directory set with `--workdir` or `-w`
+// in docker create style container options, or "" if there is none. The last
+// occurrence wins like it does for docker. Options which can't be split are
+// reported when the container is created, they have no working directory here.
+func OptionsWorkingDir(options string) string {
+	args, err := shellquote.Split(options)
+	if err != nil {
+		return ""
+	}
+	workdir := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--workdir" || arg == "-w":
+			if i+1 < len(args) {
+				i++
+				workdir = args[i]
+			}
+		case strings.HasPrefix(arg, "--workdir="):
+			workdir = strings.TrimPrefix(arg, "--workdir=")
+		case strings.HasPrefix(arg, "-w="):
+			workdir = strings.TrimPrefix(arg, "-w=")
+		case strings.HasPrefix(arg, "-w"):
+			workdir = strings.TrimPrefix(arg, "-w")
+		}
+	}
+	return workdir
+}
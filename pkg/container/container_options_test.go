@@ -0,0 +1,26 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionsWorkingDir(t *testing.T) {
+	for options, workdir := range map[string]string{
+		"":                                 "",
+		"--cpus 2":                         "",
+		"--workdir /app":                   "/app",
+		"--workdir=/app":                   "/app",
+		"-w /app --cpus 2":                 "/app",
+		"-w=/app":                          "/app",
+		"-w/app":                           "/app",
+		"--workdir /first -w /second":      "/second",
+		"--workdir '/path with spaces'":    "/path with spaces",
+		"--env FOO=bar --workdir":          "",
+		"--workdir '/unterminated":         "",
+		"--hostname build -w /app -e FOO=": "/app",
+	} {
+		assert.Equal(t, workdir, OptionsWorkingDir(options), options)
+	}
+}
@@ -5,31 +5,36 @@ import (
 	"io"
 
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 	"github.com/nektos/act/pkg/common"
 )
 
 // NewContainerInput the input for the New function
 type NewContainerInput struct {
-	Image          string
-	Username       string
-	Password       string
-	Entrypoint     []string
-	Cmd            []string
-	WorkingDir     string
-	Env            []string
-	Binds          []string
-	Mounts         map[string]string
-	Name           string
-	Stdout         io.Writer
-	Stderr         io.Writer
-	NetworkMode    string
-	Privileged     bool
-	UsernsMode     string
-	Platform       string
-	Options        string
-	NetworkAliases []string
-	ExposedPorts   nat.PortSet
-	PortBindings   nat.PortMap
+	Image           string
+	Username        string
+	Password        string
+	Entrypoint      []string
+	ClearEntrypoint bool
+	Cmd             []string
+	WorkingDir      string
+	Env             []string
+	Binds           []string
+	Mounts          map[string]string
+	Tmpfs           map[string]string
+	Name            string
+	Stdout          io.Writer
+	Stderr          io.Writer
+	NetworkMode     string
+	Privileged      bool
+	UsernsMode      string
+	Platform        string
+	Options         string
+	NetworkAliases  []string
+	ExposedPorts    nat.PortSet
+	PortBindings    nat.PortMap
+	ShmSize         int64
+	Ulimits         []*units.Ulimit
 }
 
 // FileEntry is a file to copy to a container
@@ -56,6 +61,12 @@ type Container interface {
 	ReplaceLogWriter(io.Writer, io.Writer) (io.Writer, io.Writer)
 }
 
+// ContainerWithID is implemented by the containers that have a docker container id
+type ContainerWithID interface {
+	// ID is the id of the container once it is created or found, empty otherwise
+	ID() string
+}
+
 // NewDockerBuildExecutorInput the input for the NewDockerBuildExecutor function
 type NewDockerBuildExecutorInput struct {
 	ContextDir   string
@@ -63,6 +74,7 @@ type NewDockerBuildExecutorInput struct {
 	BuildContext io.Reader
 	ImageTag     string
 	Platform     string
+	BuildArgs    map[string]string
 }
 
 // NewDockerPullExecutorInput the input for the NewDockerPullExecutor function
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/archive"
 
 	// github.com/docker/docker/builder/dockerignore is deprecated
@@ -37,37 +38,50 @@ func NewDockerBuildExecutor(input NewDockerBuildExecutorInput) common.Executor {
 		}
 		defer cli.Close()
 
-		logger.Debugf("Building image from '%v'", input.ContextDir)
+		return buildImage(ctx, cli, input)
+	}
+}
 
-		tags := []string{input.ImageTag}
-		options := types.ImageBuildOptions{
-			Tags:        tags,
-			Remove:      true,
-			Platform:    input.Platform,
-			AuthConfigs: LoadDockerAuthConfigs(ctx),
-			Dockerfile:  input.Dockerfile,
-		}
-		var buildContext io.ReadCloser
-		if input.BuildContext != nil {
-			buildContext = io.NopCloser(input.BuildContext)
-		} else {
-			buildContext, err = createBuildContext(ctx, input.ContextDir, input.Dockerfile)
-		}
-		if err != nil {
-			return err
+func buildImage(ctx context.Context, cli client.APIClient, input NewDockerBuildExecutorInput) error {
+	logger := common.Logger(ctx)
+	logger.Debugf("Building image from '%v'", input.ContextDir)
+
+	tags := []string{input.ImageTag}
+	options := types.ImageBuildOptions{
+		Tags:        tags,
+		Remove:      true,
+		Platform:    input.Platform,
+		AuthConfigs: LoadDockerAuthConfigs(ctx),
+		Dockerfile:  input.Dockerfile,
+	}
+	if len(input.BuildArgs) > 0 {
+		options.BuildArgs = make(map[string]*string, len(input.BuildArgs))
+		for k, v := range input.BuildArgs {
+			v := v
+			options.BuildArgs[k] = &v
 		}
+	}
+	var buildContext io.ReadCloser
+	var err error
+	if input.BuildContext != nil {
+		buildContext = io.NopCloser(input.BuildContext)
+	} else {
+		buildContext, err = createBuildContext(ctx, input.ContextDir, input.Dockerfile)
+	}
+	if err != nil {
+		return err
+	}
 
-		defer buildContext.Close()
+	defer buildContext.Close()
 
-		logger.Debugf("Creating image from context dir '%s' with tag '%s' and platform '%s'", input.ContextDir, input.ImageTag, input.Platform)
-		resp, err := cli.ImageBuild(ctx, buildContext, options)
+	logger.Debugf("Creating image from context dir '%s' with tag '%s' and platform '%s'", input.ContextDir, input.ImageTag, input.Platform)
+	resp, err := cli.ImageBuild(ctx, buildContext, options)
 
-		err = logDockerResponse(logger, resp.Body, err != nil)
-		if err != nil {
-			return err
-		}
-		return nil
+	err = logDockerResponse(logger, resp.Body, err != nil)
+	if err != nil {
+		return err
 	}
+	return nil
 }
 func createBuildContext(ctx context.Context, contextDir string, relDockerfile string) (io.ReadCloser, error) {
 	common.Logger(ctx).Debugf("Creating archive for build context dir '%s' with relative dockerfile '%s'", contextDir, relDockerfile)
@@ -0,0 +1,70 @@
+package container
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (m *mockDockerClient) ImageBuild(ctx context.Context, buildContext io.Reader, options types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+	args := m.Called(ctx, buildContext, options)
+	return args.Get(0).(types.ImageBuildResponse), args.Error(1)
+}
+
+func TestDockerBuildImage(t *testing.T) {
+	ctx := context.Background()
+
+	client := &mockDockerClient{}
+	client.On("ImageBuild", ctx, mock.Anything, mock.MatchedBy(func(options types.ImageBuildOptions) bool {
+		return assert.ObjectsAreEqual([]string{"act-owner-repo-dockeraction:0123abcd"}, options.Tags) &&
+			options.Dockerfile == "Dockerfile" &&
+			options.Remove
+	})).Return(types.ImageBuildResponse{
+		Body: io.NopCloser(strings.NewReader(`{"stream":"Successfully built"}`)),
+	}, nil)
+
+	err := buildImage(ctx, client, NewDockerBuildExecutorInput{
+		ContextDir:   "/action",
+		Dockerfile:   "Dockerfile",
+		ImageTag:     "act-owner-repo-dockeraction:0123abcd",
+		BuildContext: strings.NewReader("tar"),
+	})
+	assert.NoError(t, err)
+
+	client.AssertExpectations(t)
+}
+
+func TestDockerBuildImageBuildArgs(t *testing.T) {
+	ctx := context.Background()
+
+	proxy := "http://proxy:3128"
+	version := "1.2.3"
+	client := &mockDockerClient{}
+	client.On("ImageBuild", ctx, mock.Anything, mock.MatchedBy(func(options types.ImageBuildOptions) bool {
+		return assert.ObjectsAreEqual(map[string]*string{
+			"HTTP_PROXY": &proxy,
+			"VERSION":    &version,
+		}, options.BuildArgs)
+	})).Return(types.ImageBuildResponse{
+		Body: io.NopCloser(strings.NewReader(`{"stream":"Successfully built"}`)),
+	}, nil)
+
+	err := buildImage(ctx, client, NewDockerBuildExecutorInput{
+		ContextDir:   "/action",
+		Dockerfile:   "Dockerfile",
+		ImageTag:     "act-owner-repo-dockeraction:0123abcd",
+		BuildContext: strings.NewReader("tar"),
+		BuildArgs: map[string]string{
+			"HTTP_PROXY": proxy,
+			"VERSION":    version,
+		},
+	})
+	assert.NoError(t, err)
+
+	client.AssertExpectations(t)
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
 	"github.com/nektos/act/pkg/common"
 )
 
@@ -17,28 +18,7 @@ func NewDockerNetworkCreateExecutor(name string) common.Executor {
 		}
 		defer cli.Close()
 
-		// Only create the network if it doesn't exist
-		networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
-		if err != nil {
-			return err
-		}
-		common.Logger(ctx).Debugf("%v", networks)
-		for _, network := range networks {
-			if network.Name == name {
-				common.Logger(ctx).Debugf("Network %v exists", name)
-				return nil
-			}
-		}
-
-		_, err = cli.NetworkCreate(ctx, name, types.NetworkCreate{
-			Driver: "bridge",
-			Scope:  "local",
-		})
-		if err != nil {
-			return err
-		}
-
-		return nil
+		return CreateNetwork(ctx, cli, name)
 	}
 }
 
@@ -50,30 +30,57 @@ func NewDockerNetworkRemoveExecutor(name string) common.Executor {
 		}
 		defer cli.Close()
 
-		// Make sure that all network of the specified name are removed
-		// cli.NetworkRemove refuses to remove a network if there are duplicates
-		networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
-		if err != nil {
-			return err
+		return RemoveNetwork(ctx, cli, name)
+	}
+}
+
+// CreateNetwork creates a bridge network with the given name unless it exists
+func CreateNetwork(ctx context.Context, cli client.APIClient, name string) error {
+	// Only create the network if it doesn't exist
+	networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return err
+	}
+	common.Logger(ctx).Debugf("%v", networks)
+	for _, network := range networks {
+		if network.Name == name {
+			common.Logger(ctx).Debugf("Network %v exists", name)
+			return nil
 		}
-		common.Logger(ctx).Debugf("%v", networks)
-		for _, network := range networks {
-			if network.Name == name {
-				result, err := cli.NetworkInspect(ctx, network.ID, types.NetworkInspectOptions{})
-				if err != nil {
-					return err
-				}
+	}
+
+	_, err = cli.NetworkCreate(ctx, name, types.NetworkCreate{
+		Driver: "bridge",
+		Scope:  "local",
+	})
+	return err
+}
 
-				if len(result.Containers) == 0 {
-					if err = cli.NetworkRemove(ctx, network.ID); err != nil {
-						common.Logger(ctx).Debugf("%v", err)
-					}
-				} else {
-					common.Logger(ctx).Debugf("Refusing to remove network %v because it still has active endpoints", name)
+// RemoveNetwork removes all networks with the given name that have no active endpoints
+func RemoveNetwork(ctx context.Context, cli client.APIClient, name string) error {
+	// Make sure that all network of the specified name are removed
+	// cli.NetworkRemove refuses to remove a network if there are duplicates
+	networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return err
+	}
+	common.Logger(ctx).Debugf("%v", networks)
+	for _, network := range networks {
+		if network.Name == name {
+			result, err := cli.NetworkInspect(ctx, network.ID, types.NetworkInspectOptions{})
+			if err != nil {
+				return err
+			}
+
+			if len(result.Containers) == 0 {
+				if err = cli.NetworkRemove(ctx, network.ID); err != nil {
+					common.Logger(ctx).Debugf("%v", err)
 				}
+			} else {
+				common.Logger(ctx).Debugf("Refusing to remove network %v because it still has active endpoints", name)
 			}
 		}
-
-		return err
 	}
+
+	return nil
 }
@@ -0,0 +1,59 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (m *mockDockerClient) NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).([]types.NetworkResource), args.Error(1)
+}
+
+func (m *mockDockerClient) NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error) {
+	args := m.Called(ctx, name, options)
+	return args.Get(0).(types.NetworkCreateResponse), args.Error(1)
+}
+
+func (m *mockDockerClient) NetworkInspect(ctx context.Context, networkID string, options types.NetworkInspectOptions) (types.NetworkResource, error) {
+	args := m.Called(ctx, networkID, options)
+	return args.Get(0).(types.NetworkResource), args.Error(1)
+}
+
+func (m *mockDockerClient) NetworkRemove(ctx context.Context, networkID string) error {
+	args := m.Called(ctx, networkID)
+	return args.Error(0)
+}
+
+func TestDockerNetworkCreateRemove(t *testing.T) {
+	ctx := context.Background()
+	cli := &mockDockerClient{}
+	calls := []string{}
+
+	cli.On("NetworkList", ctx, mock.Anything).Return([]types.NetworkResource{}, nil).Once()
+	cli.On("NetworkCreate", ctx, "job-network", types.NetworkCreate{Driver: "bridge", Scope: "local"}).Run(func(mock.Arguments) {
+		calls = append(calls, "create")
+	}).Return(types.NetworkCreateResponse{ID: "id"}, nil)
+	cli.On("NetworkList", ctx, mock.Anything).Return([]types.NetworkResource{
+		{ID: "id", Name: "job-network"},
+		{ID: "other", Name: "other-network"},
+	}, nil)
+	cli.On("NetworkInspect", ctx, "id", mock.Anything).Return(types.NetworkResource{ID: "id"}, nil)
+	cli.On("NetworkRemove", ctx, "id").Run(func(mock.Arguments) {
+		calls = append(calls, "remove")
+	}).Return(nil)
+
+	assert.NoError(t, CreateNetwork(ctx, cli, "job-network"))
+	calls = append(calls, "job")
+	assert.NoError(t, RemoveNetwork(ctx, cli, "job-network"))
+
+	// an existing network is not created twice
+	assert.NoError(t, CreateNetwork(ctx, cli, "job-network"))
+
+	assert.Equal(t, []string{"create", "job", "remove"}, calls)
+	cli.AssertExpectations(t)
+}
@@ -15,6 +15,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/docker/cli/cli/connhelper"
@@ -179,6 +180,10 @@ func (cr *containerReference) ReplaceLogWriter(stdout io.Writer, stderr io.Write
 	return out, err
 }
 
+func (cr *containerReference) ID() string {
+	return cr.id
+}
+
 type containerReference struct {
 	cli   client.APIClient
 	id    string
@@ -213,6 +218,31 @@ func GetDockerClient(ctx context.Context) (cli client.APIClient, err error) {
 	return cli, nil
 }
 
+// VerifyDockerHost pings the docker daemon at host to fail fast with a descriptive
+// error if it can't be reached, e.g. because DOCKER_HOST points at a stale socket
+func VerifyDockerHost(ctx context.Context, host string) error {
+	opts := []client.Opt{client.WithHost(host), client.WithAPIVersionNegotiation()}
+	if strings.HasPrefix(host, "ssh://") {
+		helper, err := connhelper.GetConnectionHelper(host)
+		if err != nil {
+			return fmt.Errorf("cannot reach Docker daemon at %s: %w", host, err)
+		}
+		opts = []client.Opt{client.WithHost(helper.Host), client.WithDialContext(helper.Dialer), client.WithAPIVersionNegotiation()}
+	}
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return fmt.Errorf("cannot reach Docker daemon at %s: %w", host, err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := cli.Ping(ctx); err != nil {
+		return fmt.Errorf("cannot reach Docker daemon at %s, is the daemon running? Common socket locations are %s: %w", host, strings.Join(CommonSocketLocations, ", "), err)
+	}
+	return nil
+}
+
 func GetHostInfo(ctx context.Context) (info system.Info, err error) {
 	var cli client.APIClient
 	cli, err = GetDockerClient(ctx)
@@ -372,17 +402,89 @@ func (cr *containerReference) mergeContainerConfigs(ctx context.Context, config
 	hostConfig.Mounts = append(hostConfig.Mounts, containerConfig.HostConfig.Mounts...)
 	binds := hostConfig.Binds
 	mounts := hostConfig.Mounts
+	tmpfs := make(map[string]string, len(hostConfig.Tmpfs)+len(containerConfig.HostConfig.Tmpfs))
+	for k, v := range hostConfig.Tmpfs {
+		tmpfs[k] = v
+	}
+	for k, v := range containerConfig.HostConfig.Tmpfs {
+		tmpfs[k] = v
+	}
 	err = mergo.Merge(hostConfig, containerConfig.HostConfig, mergo.WithOverride)
 	if err != nil {
 		return nil, nil, fmt.Errorf("Cannot merge container.HostConfig options: '%s': '%w'", input.Options, err)
 	}
 	hostConfig.Binds = binds
 	hostConfig.Mounts = mounts
+	if len(tmpfs) > 0 {
+		hostConfig.Tmpfs = tmpfs
+	}
 	logger.Debugf("Merged container.HostConfig ==> %+v", hostConfig)
 
 	return config, hostConfig, nil
 }
 
+// containerConfig returns the container.Config for the container input, before merging container options
+func (cr *containerReference) containerConfig(isTerminal bool) *container.Config {
+	input := cr.input
+
+	config := &container.Config{
+		Image:        input.Image,
+		WorkingDir:   input.WorkingDir,
+		Env:          input.Env,
+		ExposedPorts: input.ExposedPorts,
+		Tty:          isTerminal,
+	}
+
+	if len(input.Cmd) != 0 {
+		config.Cmd = input.Cmd
+	}
+
+	if len(input.Entrypoint) != 0 {
+		config.Entrypoint = input.Entrypoint
+	} else if input.ClearEntrypoint {
+		// same as `docker run --entrypoint ""`, an empty list would be omitted and keep the image entrypoint
+		config.Entrypoint = []string{""}
+	}
+
+	return config
+}
+
+// hostConfig returns the container.HostConfig for the container input, before merging container options
+func (cr *containerReference) hostConfig(capAdd []string, capDrop []string) *container.HostConfig {
+	input := cr.input
+
+	mounts := make([]mount.Mount, 0)
+	for mountSource, mountTarget := range input.Mounts {
+		readOnly := false
+		if target, mode, ok := strings.Cut(mountTarget, ":"); ok {
+			mountTarget = target
+			readOnly = mode == "ro"
+		}
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeVolume,
+			Source:   mountSource,
+			Target:   mountTarget,
+			ReadOnly: readOnly,
+		})
+	}
+
+	return &container.HostConfig{
+		CapAdd:       capAdd,
+		CapDrop:      capDrop,
+		Binds:        input.Binds,
+		Mounts:       mounts,
+		Tmpfs:        input.Tmpfs,
+		NetworkMode:  container.NetworkMode(input.NetworkMode),
+		Privileged:   input.Privileged,
+		UsernsMode:   container.UsernsMode(input.UsernsMode),
+		PortBindings: input.PortBindings,
+		ShmSize:      input.ShmSize,
+		Resources: container.Resources{
+			Ulimits: input.Ulimits,
+		},
+	}
+}
+
 func (cr *containerReference) create(capAdd []string, capDrop []string) common.Executor {
 	return func(ctx context.Context) error {
 		if cr.id != "" {
@@ -392,32 +494,9 @@ func (cr *containerReference) create(capAdd []string, capDrop []string) common.E
 		isTerminal := term.IsTerminal(int(os.Stdout.Fd()))
 		input := cr.input
 
-		config := &container.Config{
-			Image:        input.Image,
-			WorkingDir:   input.WorkingDir,
-			Env:          input.Env,
-			ExposedPorts: input.ExposedPorts,
-			Tty:          isTerminal,
-		}
+		config := cr.containerConfig(isTerminal)
 		logger.Debugf("Common container.Config ==> %+v", config)
 
-		if len(input.Cmd) != 0 {
-			config.Cmd = input.Cmd
-		}
-
-		if len(input.Entrypoint) != 0 {
-			config.Entrypoint = input.Entrypoint
-		}
-
-		mounts := make([]mount.Mount, 0)
-		for mountSource, mountTarget := range input.Mounts {
-			mounts = append(mounts, mount.Mount{
-				Type:   mount.TypeVolume,
-				Source: mountSource,
-				Target: mountTarget,
-			})
-		}
-
 		var platSpecs *specs.Platform
 		if supportsContainerImagePlatform(ctx, cr.cli) && cr.input.Platform != "" {
 			desiredPlatform := strings.SplitN(cr.input.Platform, `/`, 2)
@@ -432,16 +511,7 @@ func (cr *containerReference) create(capAdd []string, capDrop []string) common.E
 			}
 		}
 
-		hostConfig := &container.HostConfig{
-			CapAdd:       capAdd,
-			CapDrop:      capDrop,
-			Binds:        input.Binds,
-			Mounts:       mounts,
-			NetworkMode:  container.NetworkMode(input.NetworkMode),
-			Privileged:   input.Privileged,
-			UsernsMode:   container.UsernsMode(input.UsernsMode),
-			PortBindings: input.PortBindings,
-		}
+		hostConfig := cr.hostConfig(capAdd, capDrop)
 		logger.Debugf("Common container.HostConfig ==> %+v", hostConfig)
 
 		config, hostConfig, err := cr.mergeContainerConfigs(ctx, config, hostConfig)
@@ -12,7 +12,12 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -62,6 +67,16 @@ type mockDockerClient struct {
 	mock.Mock
 }
 
+func (m *mockDockerClient) ServerVersion(ctx context.Context) (types.Version, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(types.Version), args.Error(1)
+}
+
+func (m *mockDockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.CreateResponse, error) {
+	args := m.Called(ctx, config, hostConfig, networkingConfig, platform, containerName)
+	return args.Get(0).(container.CreateResponse), args.Error(1)
+}
+
 func (m *mockDockerClient) ContainerExecCreate(ctx context.Context, id string, opts types.ExecConfig) (types.IDResponse, error) {
 	args := m.Called(ctx, id, opts)
 	return args.Get(0).(types.IDResponse), args.Error(1)
@@ -246,3 +261,111 @@ func TestDockerCopyTarStreamErrorInMkdir(t *testing.T) {
 
 // Type assert containerReference implements ExecutionsEnvironment
 var _ ExecutionsEnvironment = &containerReference{}
+
+func TestDockerHostConfigReadOnlyAndTmpfs(t *testing.T) {
+	ctx := context.Background()
+
+	cr := &containerReference{
+		input: &NewContainerInput{
+			Image: "alpine",
+			Binds: []string{"/host/path:/container/path:ro"},
+			Mounts: map[string]string{
+				"cache": "/cache:ro",
+			},
+			Tmpfs: map[string]string{
+				"/run": "",
+			},
+			NetworkMode: "host",
+			Options:     "--tmpfs /tmp:size=64m",
+		},
+	}
+
+	config := &container.Config{Image: cr.input.Image}
+	_, hostConfig, err := cr.mergeContainerConfigs(ctx, config, cr.hostConfig(nil, nil))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"/host/path:/container/path:ro"}, hostConfig.Binds)
+	assert.Equal(t, []mount.Mount{
+		{
+			Type:     mount.TypeVolume,
+			Source:   "cache",
+			Target:   "/cache",
+			ReadOnly: true,
+		},
+	}, hostConfig.Mounts)
+	assert.Equal(t, map[string]string{
+		"/run": "",
+		"/tmp": "size=64m",
+	}, hostConfig.Tmpfs)
+}
+
+func TestDockerCreateSetsID(t *testing.T) {
+	ctx := context.Background()
+	cli := &mockDockerClient{}
+	cli.On("ServerVersion", ctx).Return(types.Version{APIVersion: "1.43"}, nil)
+	cli.On("ContainerCreate", ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything, "job-container").Return(container.CreateResponse{ID: "0123abcd"}, nil)
+
+	cr := &containerReference{
+		cli: cli,
+		input: &NewContainerInput{
+			Image: "image",
+			Name:  "job-container",
+		},
+	}
+	assert.Equal(t, "", cr.ID())
+	assert.NoError(t, cr.create(nil, nil)(ctx))
+	assert.Equal(t, "0123abcd", cr.ID())
+	cli.AssertExpectations(t)
+}
+
+func TestDockerContainerConfigEntrypoint(t *testing.T) {
+	cr := &containerReference{
+		input: &NewContainerInput{
+			Image:      "image",
+			Entrypoint: []string{"/bin/sh", "-c"},
+		},
+	}
+	assert.Equal(t, []string{"/bin/sh", "-c"}, []string(cr.containerConfig(false).Entrypoint))
+
+	cr.input.Entrypoint = nil
+	assert.Nil(t, cr.containerConfig(false).Entrypoint)
+
+	cr.input.ClearEntrypoint = true
+	assert.Equal(t, []string{""}, []string(cr.containerConfig(false).Entrypoint))
+
+	// an explicit entrypoint wins over clearing it
+	cr.input.Entrypoint = []string{"/entrypoint.sh"}
+	assert.Equal(t, []string{"/entrypoint.sh"}, []string(cr.containerConfig(false).Entrypoint))
+
+	// like `docker run --entrypoint ""`, the options clear the entrypoint
+	cr.input.Options = `--entrypoint ""`
+	cr.input.NetworkMode = "host"
+	config, _, err := cr.mergeContainerConfigs(context.Background(), cr.containerConfig(false), cr.hostConfig(nil, nil))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{""}, []string(config.Entrypoint))
+	}
+}
+
+func TestDockerHostConfigShmSizeUlimits(t *testing.T) {
+	ctx := context.Background()
+	cr := &containerReference{
+		input: &NewContainerInput{
+			Image:       "image",
+			ShmSize:     1 << 30,
+			Ulimits:     []*units.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}},
+			NetworkMode: "host",
+		},
+	}
+
+	hostConfig := cr.hostConfig(nil, nil)
+	assert.Equal(t, int64(1<<30), hostConfig.ShmSize)
+	assert.Equal(t, []*units.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}}, hostConfig.Ulimits)
+
+	// container options win over the input
+	cr.input.Options = "--shm-size 2g --ulimit nproc=512:1024"
+	config := &container.Config{Image: cr.input.Image}
+	_, hostConfig, err := cr.mergeContainerConfigs(ctx, config, cr.hostConfig(nil, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2<<30), hostConfig.ShmSize)
+	assert.Equal(t, []*units.Ulimit{{Name: "nproc", Soft: 512, Hard: 1024}}, hostConfig.Ulimits)
+}
@@ -1,6 +1,7 @@
 package container
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -148,3 +149,10 @@ func TestGetSocketAndHostOnlySocketValidButUnusualLocation(t *testing.T) {
 	assert.Nil(t, err, "Expect no error from GetSocketAndHost")
 	assert.Equal(t, socketURI, ret.Host, "Expect host to default to unusual socket")
 }
+
+func TestVerifyDockerHostUnreachable(t *testing.T) {
+	CommonSocketLocations = originalCommonSocketLocations
+	err := VerifyDockerHost(context.Background(), "unix:///nonexistent/act/docker.sock")
+	assert.ErrorContains(t, err, "cannot reach Docker daemon at unix:///nonexistent/act/docker.sock")
+	assert.ErrorContains(t, err, "/var/run/docker.sock")
+}
@@ -46,6 +46,10 @@ func RunnerArch(ctx context.Context) string {
 	return runtime.GOOS
 }
 
+func VerifyDockerHost(ctx context.Context, host string) error {
+	return errors.New("Unsupported Operation")
+}
+
 func GetHostInfo(ctx context.Context) (info types.Info, err error) {
 	return types.Info{}, nil
 }
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 
 	"github.com/nektos/act/pkg/common"
@@ -24,37 +25,63 @@ func parseEnvFile(e Container, srcPath string, env *map[string]string) common.Ex
 		if err != nil && err != io.EOF {
 			return err
 		}
-		s := bufio.NewScanner(reader)
-		for s.Scan() {
-			line := s.Text()
-			singleLineEnv := strings.Index(line, "=")
-			multiLineEnv := strings.Index(line, "<<")
-			if singleLineEnv != -1 && (multiLineEnv == -1 || singleLineEnv < multiLineEnv) {
-				localEnv[line[:singleLineEnv]] = line[singleLineEnv+1:]
-			} else if multiLineEnv != -1 {
-				multiLineEnvContent := ""
-				multiLineEnvDelimiter := line[multiLineEnv+2:]
-				delimiterFound := false
-				for s.Scan() {
-					content := s.Text()
-					if content == multiLineEnvDelimiter {
-						delimiterFound = true
-						break
-					}
-					if multiLineEnvContent != "" {
-						multiLineEnvContent += "\n"
-					}
-					multiLineEnvContent += content
+		if err := parseEnvLines(reader, localEnv); err != nil {
+			return err
+		}
+		env = &localEnv
+		return nil
+	}
+}
+
+// LoadEnvFiles reads env files in the format of GITHUB_ENV, with `NAME=value` lines
+// and `NAME<<DELIMITER` heredocs, and merges them from left to right so later
+// files override the variables of earlier ones. Missing files are an error.
+func LoadEnvFiles(paths ...string) (map[string]string, error) {
+	env := map[string]string{}
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		err = parseEnvLines(f, env)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file '%s': %w", p, err)
+		}
+	}
+	return env, nil
+}
+
+func parseEnvLines(r io.Reader, env map[string]string) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		singleLineEnv := strings.Index(line, "=")
+		multiLineEnv := strings.Index(line, "<<")
+		if singleLineEnv != -1 && (multiLineEnv == -1 || singleLineEnv < multiLineEnv) {
+			env[line[:singleLineEnv]] = line[singleLineEnv+1:]
+		} else if multiLineEnv != -1 {
+			multiLineEnvContent := ""
+			multiLineEnvDelimiter := line[multiLineEnv+2:]
+			delimiterFound := false
+			for s.Scan() {
+				content := s.Text()
+				if content == multiLineEnvDelimiter {
+					delimiterFound = true
+					break
 				}
-				if !delimiterFound {
-					return fmt.Errorf("invalid format delimiter '%v' not found before end of file", multiLineEnvDelimiter)
+				if multiLineEnvContent != "" {
+					multiLineEnvContent += "\n"
 				}
-				localEnv[line[:multiLineEnv]] = multiLineEnvContent
-			} else {
-				return fmt.Errorf("invalid format '%v', expected a line with '=' or '<<'", line)
+				multiLineEnvContent += content
+			}
+			if !delimiterFound {
+				return fmt.Errorf("invalid format delimiter '%v' not found before end of file", multiLineEnvDelimiter)
 			}
+			env[line[:multiLineEnv]] = multiLineEnvContent
+		} else {
+			return fmt.Errorf("invalid format '%v', expected a line with '=' or '<<'", line)
 		}
-		env = &localEnv
-		return nil
 	}
+	return nil
 }
@@ -0,0 +1,38 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.env")
+	override := filepath.Join(dir, "override.env")
+	assert.NoError(t, os.WriteFile(base, []byte("SHARED=base\nBASE_ONLY=1\nMULTI<<EOF\nline 1\nline 2\nEOF\n"), 0o600))
+	assert.NoError(t, os.WriteFile(override, []byte("SHARED=override\nQUOTED=\"kept as is\"\n"), 0o600))
+
+	env, err := LoadEnvFiles(base, override)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"SHARED":    "override",
+		"BASE_ONLY": "1",
+		"MULTI":     "line 1\nline 2",
+		"QUOTED":    "\"kept as is\"",
+	}, env)
+
+	env, err = LoadEnvFiles(override, base)
+	assert.NoError(t, err)
+	assert.Equal(t, "base", env["SHARED"])
+
+	invalid := filepath.Join(dir, "invalid.env")
+	assert.NoError(t, os.WriteFile(invalid, []byte("HEREDOC<<EOF\nno end\n"), 0o600))
+	_, err = LoadEnvFiles(base, invalid)
+	assert.EqualError(t, err, "failed to read env file '"+invalid+"': invalid format delimiter 'EOF' not found before end of file")
+
+	_, err = LoadEnvFiles(filepath.Join(dir, "missing.env"))
+	assert.Error(t, err)
+}
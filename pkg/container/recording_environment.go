@@ -0,0 +1,164 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"path"
+	"sync"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// RecordedExec is a command a RecordingEnvironment was asked to execute
+type RecordedExec struct {
+	Command []string
+	Env     map[string]string
+	User    string
+	WorkDir string
+}
+
+// RecordingEnvironment is an ExecutionsEnvironment which doesn't run anything,
+// it records the commands and files of the steps instead. It allows testing the
+// control flow of workflows (matrices, conditions, env) without docker.
+// It is safe for concurrent use by the jobs of a run.
+type RecordingEnvironment struct {
+	LinuxContainerEnvironmentExtensions
+
+	// Output optionally returns what an executed command writes to the log
+	// writer of its step, e.g. workflow commands like `::add-mask::`. With jobs
+	// running in parallel it goes to the log writer of the step started last.
+	Output func(exec RecordedExec) string
+
+	mu    sync.Mutex
+	execs []RecordedExec
+	files map[string]string
+	out   io.Writer
+}
+
+// Execs returns the recorded commands in the order they were executed
+func (e *RecordingEnvironment) Execs() []RecordedExec {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]RecordedExec{}, e.execs...)
+}
+
+// Files returns the content of the copied files by their path
+func (e *RecordingEnvironment) Files() map[string]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	files := make(map[string]string, len(e.files))
+	for k, v := range e.files {
+		files[k] = v
+	}
+	return files
+}
+
+func (e *RecordingEnvironment) Create(_ []string, _ []string) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (e *RecordingEnvironment) Copy(destPath string, files ...*FileEntry) common.Executor {
+	return func(ctx context.Context) error {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if e.files == nil {
+			e.files = map[string]string{}
+		}
+		for _, f := range files {
+			e.files[path.Join(destPath, f.Name)] = f.Body
+		}
+		return nil
+	}
+}
+
+func (e *RecordingEnvironment) CopyTarStream(_ context.Context, _ string, _ io.Reader) error {
+	return nil
+}
+
+func (e *RecordingEnvironment) CopyDir(_ string, _ string, _ bool) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+// GetContainerArchive returns an empty archive, so file commands of steps have no effect
+func (e *RecordingEnvironment) GetContainerArchive(_ context.Context, _ string) (io.ReadCloser, error) {
+	buf := &bytes.Buffer{}
+	if err := tar.NewWriter(buf).Close(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(buf), nil
+}
+
+func (e *RecordingEnvironment) Pull(_ bool) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (e *RecordingEnvironment) Start(_ bool) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (e *RecordingEnvironment) Exec(command []string, env map[string]string, user, workdir string) common.Executor {
+	return func(ctx context.Context) error {
+		recordedEnv := make(map[string]string, len(env))
+		for k, v := range env {
+			recordedEnv[k] = v
+		}
+		exec := RecordedExec{
+			Command: append([]string{}, command...),
+			Env:     recordedEnv,
+			User:    user,
+			WorkDir: workdir,
+		}
+		e.mu.Lock()
+		e.execs = append(e.execs, exec)
+		out := e.out
+		e.mu.Unlock()
+		if e.Output != nil && out != nil {
+			if _, err := io.WriteString(out, e.Output(exec)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (e *RecordingEnvironment) UpdateFromEnv(_ string, _ *map[string]string) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (e *RecordingEnvironment) UpdateFromImageEnv(_ *map[string]string) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (e *RecordingEnvironment) Remove() common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (e *RecordingEnvironment) Close() common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (e *RecordingEnvironment) ReplaceLogWriter(stdout io.Writer, _ io.Writer) (io.Writer, io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	org := e.out
+	e.out = stdout
+	return org, org
+}
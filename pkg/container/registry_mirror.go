@@ -0,0 +1,48 @@
+package container
+
+import (
+	"strings"
+
+	"github.com/docker/distribution/reference"
+)
+
+// MirrorImage rewrites an image reference to the registry mirror configured for it.
+// The keys of mirrors are repository prefixes matched against the fully qualified
+// reference, so an implicit registry like in `ubuntu:20.04` is matched as
+// `docker.io/library/ubuntu:20.04`. The longest matching prefix wins and its
+// value replaces the prefix. Images without a matching mirror are returned unchanged.
+func MirrorImage(image string, mirrors map[string]string) string {
+	if len(mirrors) == 0 || image == "" {
+		return image
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return image
+	}
+	ref := named.String()
+
+	var prefix, mirror string
+	for k, v := range mirrors {
+		p := strings.TrimSuffix(k, "/")
+		if len(p) <= len(prefix) {
+			continue
+		}
+		if ref == p || strings.HasPrefix(ref, p+"/") || strings.HasPrefix(ref, p+":") || strings.HasPrefix(ref, p+"@") {
+			prefix, mirror = p, v
+		}
+	}
+	if prefix == "" {
+		return image
+	}
+	return strings.TrimSuffix(mirror, "/") + strings.TrimPrefix(ref, prefix)
+}
+
+// ImageRegistry returns the registry host of an image reference, e.g. docker.io
+// for `ubuntu:20.04`, or an empty string if the reference is invalid
+func ImageRegistry(image string) string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	return reference.Domain(named)
+}
@@ -0,0 +1,42 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirrorImage(t *testing.T) {
+	mirrors := map[string]string{
+		"docker.io/library": "mirror.internal",
+		"docker.io/nektos":  "mirror.internal/nektos-mirror",
+	}
+
+	tables := []struct {
+		image    string
+		expected string
+	}{
+		{"ubuntu:20.04", "mirror.internal/ubuntu:20.04"},
+		{"ubuntu", "mirror.internal/ubuntu"},
+		{"docker.io/library/ubuntu:20.04", "mirror.internal/ubuntu:20.04"},
+		{"nektos/act-environments-ubuntu:18.04", "mirror.internal/nektos-mirror/act-environments-ubuntu:18.04"},
+		{"ghcr.io/catthehacker/ubuntu:act-latest", "ghcr.io/catthehacker/ubuntu:act-latest"},
+		{"docker.io/libraryx/foo:1", "docker.io/libraryx/foo:1"},
+	}
+
+	for _, table := range tables {
+		t.Run(table.image, func(t *testing.T) {
+			assert.Equal(t, table.expected, MirrorImage(table.image, mirrors))
+		})
+	}
+
+	assert.Equal(t, "ubuntu:20.04", MirrorImage("ubuntu:20.04", nil))
+	assert.Equal(t, "mirror.internal/library/ubuntu:20.04", MirrorImage("ubuntu:20.04", map[string]string{"docker.io": "mirror.internal"}))
+}
+
+func TestImageRegistry(t *testing.T) {
+	assert.Equal(t, "docker.io", ImageRegistry("ubuntu:20.04"))
+	assert.Equal(t, "ghcr.io", ImageRegistry("ghcr.io/catthehacker/ubuntu:act-latest"))
+	assert.Equal(t, "localhost:5000", ImageRegistry("localhost:5000/app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"))
+	assert.Equal(t, "", ImageRegistry("Invalid Image"))
+}
@@ -180,6 +180,10 @@ func (impl *interperterImpl) fromJSON(value reflect.Value) (interface{}, error)
 	return data, nil
 }
 
+// hashFiles returns the SHA-256 of the SHA-256 digests of the files below the
+// working directory matching the patterns, as GitHub computes it. Like on GitHub
+// it is an empty string when no file matches, while matched zero-byte files
+// still produce a digest.
 func (impl *interperterImpl) hashFiles(paths ...reflect.Value) (string, error) {
 	var ps []gitignore.Pattern
 
@@ -229,19 +233,25 @@ func (impl *interperterImpl) hashFiles(paths ...reflect.Value) (string, error) {
 			return "", fmt.Errorf("Unable to os.Open: %v", err)
 		}
 
-		if _, err := io.Copy(hasher, f); err != nil {
+		fileHasher := sha256.New()
+		if _, err := io.Copy(fileHasher, f); err != nil {
 			return "", fmt.Errorf("Unable to io.Copy: %v", err)
 		}
 
 		if err := f.Close(); err != nil {
 			return "", fmt.Errorf("Unable to Close file: %v", err)
 		}
+
+		hasher.Write(fileHasher.Sum(nil))
 	}
 
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
 func (impl *interperterImpl) getNeedsTransitive(job *model.Job) []string {
+	if job == nil {
+		return nil
+	}
 	needs := job.Needs()
 
 	for _, need := range needs {
@@ -261,7 +271,7 @@ func (impl *interperterImpl) jobSuccess() (bool, error) {
 	jobNeeds := impl.getNeedsTransitive(impl.config.Run.Job())
 
 	for _, needs := range jobNeeds {
-		if jobs[needs].Result != "success" {
+		if job, ok := jobs[needs]; !ok || job == nil || job.Result != "success" {
 			return false, nil
 		}
 	}
@@ -278,7 +288,7 @@ func (impl *interperterImpl) jobFailure() (bool, error) {
 	jobNeeds := impl.getNeedsTransitive(impl.config.Run.Job())
 
 	for _, needs := range jobNeeds {
-		if jobs[needs].Result == "failure" {
+		if job, ok := jobs[needs]; ok && job != nil && job.Result == "failure" {
 			return true, nil
 		}
 	}
@@ -2,10 +2,12 @@ package exprparser
 
 import (
 	"encoding"
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/nektos/act/pkg/model"
 	"github.com/rhysd/actionlint"
@@ -25,6 +27,31 @@ type EvaluationEnvironment struct {
 	Needs     map[string]Needs
 	Inputs    map[string]interface{}
 	HashFiles func([]reflect.Value) (interface{}, error)
+	// SecretLookup resolves secrets missing from Secrets when they are referenced
+	SecretLookup func(name string) (string, bool)
+}
+
+// lazySecrets is the secrets context if secrets are resolved on demand
+type lazySecrets struct {
+	static map[string]string
+	lookup func(name string) (string, bool)
+}
+
+func (s lazySecrets) get(name string) interface{} {
+	for k, v := range s.static {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	if v, ok := s.lookup(name); ok {
+		return v
+	}
+	return nil
+}
+
+// MarshalJSON only exposes the secrets known upfront, e.g. for toJSON(secrets)
+func (s lazySecrets) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.static)
 }
 
 type Needs struct {
@@ -69,6 +96,11 @@ type Interpreter interface {
 type interperterImpl struct {
 	env    *EvaluationEnvironment
 	config Config
+	// parsed caches the syntax trees of the evaluated expressions. They don't
+	// depend on the environment, which is fixed for an interpreter anyway.
+	parsedMu sync.Mutex
+	parsed   map[string]actionlint.ExprNode
+	parses   int
 }
 
 func NewInterpeter(env *EvaluationEnvironment, config Config) Interpreter {
@@ -78,15 +110,35 @@ func NewInterpeter(env *EvaluationEnvironment, config Config) Interpreter {
 	}
 }
 
+// parse returns the syntax tree of an expression, expressions evaluated before are parsed once
+func (impl *interperterImpl) parse(input string) (actionlint.ExprNode, error) {
+	impl.parsedMu.Lock()
+	defer impl.parsedMu.Unlock()
+	if exprNode, ok := impl.parsed[input]; ok {
+		return exprNode, nil
+	}
+
+	impl.parses++
+	parser := actionlint.NewExprParser()
+	exprNode, err := parser.Parse(actionlint.NewExprLexer(input + "}}"))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse: %s", err.Message)
+	}
+	if impl.parsed == nil {
+		impl.parsed = map[string]actionlint.ExprNode{}
+	}
+	impl.parsed[input] = exprNode
+	return exprNode, nil
+}
+
 func (impl *interperterImpl) Evaluate(input string, defaultStatusCheck DefaultStatusCheck) (interface{}, error) {
 	input = strings.TrimPrefix(input, "${{")
 	if defaultStatusCheck != DefaultStatusCheckNone && input == "" {
 		input = "success()"
 	}
-	parser := actionlint.NewExprParser()
-	exprNode, err := parser.Parse(actionlint.NewExprLexer(input + "}}"))
+	exprNode, err := impl.parse(input)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to parse: %s", err.Message)
+		return nil, err
 	}
 
 	if defaultStatusCheck != DefaultStatusCheckNone {
@@ -169,6 +221,9 @@ func (impl *interperterImpl) evaluateVariable(variableNode *actionlint.VariableN
 	case "runner":
 		return impl.env.Runner, nil
 	case "secrets":
+		if impl.env.SecretLookup != nil {
+			return lazySecrets{static: impl.env.Secrets, lookup: impl.env.SecretLookup}, nil
+		}
 		return impl.env.Secrets, nil
 	case "vars":
 		return impl.env.Vars, nil
@@ -248,6 +303,10 @@ func (impl *interperterImpl) getPropertyValue(left reflect.Value, property strin
 		return impl.getPropertyValue(left.Elem(), property)
 
 	case reflect.Struct:
+		if secrets, ok := left.Interface().(lazySecrets); ok {
+			return secrets.get(property), nil
+		}
+
 		leftType := left.Type()
 		for i := 0; i < leftType.NumField(); i++ {
 			jsonName := leftType.Field(i).Tag.Get("json")
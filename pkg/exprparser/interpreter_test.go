@@ -69,6 +69,10 @@ func TestOperators(t *testing.T) {
 		{`true || false`, true, "or", ""},
 		{`fromJSON('{}') && true`, true, "and-boolean-object", ""},
 		{`fromJSON('{}') || false`, make(map[string]interface{}), "or-boolean-object", ""},
+		{`env.MISSING || 'fallback'`, "fallback", "or-default", ""},
+		{`env.IMAGE || 'ubuntu:latest'`, "node:20", "or-default-set", ""},
+		{`'a' && 'b'`, "b", "and-value", ""},
+		{`'' && 'b'`, "", "and-value-falsy", ""},
 		{"github.event.commits[0].author.username != github.event.commits[1].author.username", true, "property-comparison1", ""},
 		{"github.event.commits[0].author.username1 != github.event.commits[1].author.username", true, "property-comparison2", ""},
 		{"github.event.commits[0].author.username != github.event.commits[1].author.username1", true, "property-comparison3", ""},
@@ -77,6 +81,9 @@ func TestOperators(t *testing.T) {
 	}
 
 	env := &EvaluationEnvironment{
+		Env: map[string]string{
+			"IMAGE": "node:20",
+		},
 		Github: &model.GithubContext{
 			Action: "push",
 			Event: map[string]interface{}{
@@ -625,3 +632,65 @@ func TestContexts(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateParsesOnce(t *testing.T) {
+	env := &EvaluationEnvironment{
+		Env: map[string]string{"NAME": "first"},
+		Job: &model.JobContext{Status: "success"},
+	}
+	interpreter := NewInterpeter(env, Config{Context: "step"}).(*interperterImpl)
+
+	for i := 0; i < 10; i++ {
+		output, err := interpreter.Evaluate("env.NAME", DefaultStatusCheckNone)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", output)
+	}
+	assert.Equal(t, 1, interpreter.parses)
+
+	// the cached expression sees changes of the environment
+	env.Env["NAME"] = "second"
+	output, err := interpreter.Evaluate("env.NAME", DefaultStatusCheckNone)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", output)
+
+	// the default status check doesn't change the cached expression
+	output, err = interpreter.Evaluate("env.NAME == 'second'", DefaultStatusCheckSuccess)
+	assert.NoError(t, err)
+	assert.Equal(t, true, output)
+	output, err = interpreter.Evaluate("env.NAME == 'second'", DefaultStatusCheckNone)
+	assert.NoError(t, err)
+	assert.Equal(t, true, output)
+	assert.Equal(t, 2, interpreter.parses)
+
+	_, err = interpreter.Evaluate("env.NAME ==", DefaultStatusCheckNone)
+	assert.Error(t, err)
+}
+
+func BenchmarkEvaluateRepeated(b *testing.B) {
+	env := &EvaluationEnvironment{
+		Env: map[string]string{"IMAGE": "node:20"},
+	}
+	for _, bc := range []struct {
+		name   string
+		shared bool
+	}{
+		{"shared-interpreter", true},
+		{"interpreter-per-evaluation", false},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			parses := 0
+			for i := 0; i < b.N; i++ {
+				interpreter := NewInterpeter(env, Config{}).(*interperterImpl)
+				for j := 0; j < 100; j++ {
+					if !bc.shared {
+						parses += interpreter.parses
+						interpreter = NewInterpeter(env, Config{}).(*interperterImpl)
+					}
+					_, _ = interpreter.Evaluate("env.IMAGE || 'ubuntu:latest'", DefaultStatusCheckNone)
+				}
+				parses += interpreter.parses
+			}
+			b.ReportMetric(float64(parses)/float64(b.N), "parses/op")
+		})
+	}
+}
@@ -58,8 +58,25 @@ type ActionRuns struct {
 	PostIf     string            `yaml:"post-if"`
 	Image      string            `yaml:"image"`
 	Entrypoint string            `yaml:"entrypoint"`
-	Args       []string          `yaml:"args"`
-	Steps      []Step            `yaml:"steps"`
+	// ClearEntrypoint is set by an empty entrypoint, which clears the entrypoint of the image
+	ClearEntrypoint bool              `yaml:"-"`
+	Args            []string          `yaml:"args"`
+	BuildArgs       map[string]string `yaml:"build-args"` // act extension, not part of the action.yml of GitHub
+	Steps           []Step            `yaml:"steps"`
+}
+
+// UnmarshalYAML records whether the entrypoint is set to an empty string
+func (r *ActionRuns) UnmarshalYAML(node *yaml.Node) error {
+	type actionRuns ActionRuns
+	if err := node.Decode((*actionRuns)(r)); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "entrypoint" {
+			r.ClearEntrypoint = r.Entrypoint == ""
+		}
+	}
+	return nil
 }
 
 // Action describes a metadata file for GitHub actions. The metadata filename must be either action.yml or action.yaml. The data in the metadata file defines the inputs, outputs and main entrypoint for your action.
@@ -70,6 +87,7 @@ type Action struct {
 	Inputs      map[string]Input  `yaml:"inputs"`
 	Outputs     map[string]Output `yaml:"outputs"`
 	Runs        ActionRuns        `yaml:"runs"`
+	Defaults    Defaults          `yaml:"defaults"`
 	Branding    struct {
 		Color string `yaml:"color"`
 		Icon  string `yaml:"icon"`
@@ -78,9 +96,11 @@ type Action struct {
 
 // Input parameters allow you to specify data that the action expects to use during runtime. GitHub stores input parameters as environment variables. Input ids with uppercase letters are converted to lowercase during runtime. We recommended using lowercase input ids.
 type Input struct {
-	Description string `yaml:"description"`
-	Required    bool   `yaml:"required"`
-	Default     string `yaml:"default"`
+	Description        string `yaml:"description"`
+	Required           bool   `yaml:"required"`
+	Default            string `yaml:"default"`
+	Type               string `yaml:"type"`
+	DeprecationMessage string `yaml:"deprecationMessage"`
 }
 
 // Output parameters allow you to declare data that an action sets. Actions that run later in a workflow can use the output data set in previously run actions. For example, if you had an action that performed the addition of two inputs (x + y = z), the action could output the sum (z) for other actions to use as an input.
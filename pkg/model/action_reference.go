@@ -0,0 +1,83 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ActionReference is an action, docker image or reusable workflow referenced by `uses`
+type ActionReference struct {
+	Type  StepType
+	Uses  string
+	Owner string
+	Repo  string
+	Path  string
+	Ref   string
+	Image string
+}
+
+var actionReferenceRegex = regexp.MustCompile(`^([^/@]+)/([^/@]+)(/([^@]*))?@(.+)$`)
+
+// ParseActionReference classifies the `uses` value of a step
+func ParseActionReference(uses string) (ActionReference, error) {
+	step := &Step{Uses: uses}
+	ref := ActionReference{
+		Type: step.Type(),
+		Uses: uses,
+	}
+	switch ref.Type {
+	case StepTypeUsesDockerURL:
+		ref.Image = strings.TrimPrefix(uses, "docker://")
+	case StepTypeUsesActionLocal, StepTypeReusableWorkflowLocal:
+		ref.Path = uses
+	case StepTypeUsesActionRemote, StepTypeReusableWorkflowRemote:
+		matches := actionReferenceRegex.FindStringSubmatch(uses)
+		if matches == nil {
+			return ref, fmt.Errorf("'%s' is not a valid action reference, expected '{owner}/{repo}[/path]@{ref}'", uses)
+		}
+		ref.Owner = matches[1]
+		ref.Repo = matches[2]
+		ref.Path = matches[4]
+		ref.Ref = matches[5]
+	default:
+		return ref, fmt.Errorf("'%s' is not a valid action reference", uses)
+	}
+	return ref, nil
+}
+
+// ActionReferences returns the distinct action, docker and reusable workflow references
+// of all jobs and steps of the workflow, sorted by their `uses` value
+func (w *Workflow) ActionReferences() ([]ActionReference, error) {
+	uses := map[string]bool{}
+	for _, job := range w.Jobs {
+		if job == nil {
+			continue
+		}
+		if job.Uses != "" {
+			uses[job.Uses] = true
+		}
+		for _, step := range job.Steps {
+			if step != nil && step.Uses != "" {
+				uses[step.Uses] = true
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(uses))
+	for u := range uses {
+		sorted = append(sorted, u)
+	}
+	sort.Strings(sorted)
+
+	refs := make([]ActionReference, 0, len(sorted))
+	for _, u := range sorted {
+		ref, err := ParseActionReference(u)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflow_ActionReferences(t *testing.T) {
+	yaml := `
+name: action-references
+on: push
+
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - uses: actions/checkout@v3
+    - uses: ./actions/local
+    - uses: docker://alpine:3.18
+    - uses: github/codeql-action/init@v2
+    - run: echo build
+  test:
+    runs-on: ubuntu-latest
+    steps:
+    - uses: actions/checkout@v3
+  reusable:
+    uses: octo-org/example-repo/.github/workflows/reusable.yml@main
+`
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	refs, err := workflow.ActionReferences()
+	assert.NoError(t, err)
+	assert.Equal(t, []ActionReference{
+		{Type: StepTypeUsesActionLocal, Uses: "./actions/local", Path: "./actions/local"},
+		{Type: StepTypeUsesActionRemote, Uses: "actions/checkout@v3", Owner: "actions", Repo: "checkout", Ref: "v3"},
+		{Type: StepTypeUsesDockerURL, Uses: "docker://alpine:3.18", Image: "alpine:3.18"},
+		{Type: StepTypeUsesActionRemote, Uses: "github/codeql-action/init@v2", Owner: "github", Repo: "codeql-action", Path: "init", Ref: "v2"},
+		{Type: StepTypeReusableWorkflowRemote, Uses: "octo-org/example-repo/.github/workflows/reusable.yml@main", Owner: "octo-org", Repo: "example-repo", Path: ".github/workflows/reusable.yml", Ref: "main"},
+	}, refs)
+}
+
+func TestParseActionReferenceMissingRef(t *testing.T) {
+	_, err := ParseActionReference("actions/checkout")
+	assert.EqualError(t, err, "'actions/checkout' is not a valid action reference, expected '{owner}/{repo}[/path]@{ref}'")
+}
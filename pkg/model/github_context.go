@@ -173,7 +173,9 @@ func (ghc *GithubContext) SetRepositoryAndOwner(ctx context.Context, githubInsta
 		}
 		ghc.Repository = repo
 	}
-	ghc.RepositoryOwner = strings.Split(ghc.Repository, "/")[0]
+	if ghc.RepositoryOwner == "" {
+		ghc.RepositoryOwner = strings.Split(ghc.Repository, "/")[0]
+	}
 }
 
 func (ghc *GithubContext) SetRefTypeAndName() {
@@ -143,16 +143,22 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 				return nil, fmt.Errorf("error occurring when resetting io pointer in '%s': %w", wf.workflowDirEntry.Name(), err)
 			}
 
-			workflow.File = wf.workflowDirEntry.Name()
-			if workflow.Name == "" {
-				workflow.Name = wf.workflowDirEntry.Name()
+			// name the workflow by its path relative to the workflows dir, like GitHub
+			file := wf.workflowDirEntry.Name()
+			if fi.IsDir() {
+				if rel, err := filepath.Rel(path, filepath.Join(wf.dirPath, file)); err == nil {
+					file = filepath.ToSlash(rel)
+				}
 			}
+			workflow.File = file
+			workflow.Name = workflow.DisplayName(file)
 
 			err = validateJobName(workflow)
 			if err != nil {
 				_ = f.Close()
 				return nil, err
 			}
+			warnLint(workflow)
 
 			wp.workflows = append(wp.workflows, workflow)
 			_ = f.Close()
@@ -163,8 +169,6 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 }
 
 func NewSingleWorkflowPlanner(name string, f io.Reader) (WorkflowPlanner, error) {
-	wp := new(workflowPlanner)
-
 	log.Debugf("Reading workflow %s", name)
 	workflow, err := ReadWorkflow(f)
 	if err != nil {
@@ -174,18 +178,21 @@ func NewSingleWorkflowPlanner(name string, f io.Reader) (WorkflowPlanner, error)
 		return nil, fmt.Errorf("workflow is not valid. '%s': %w", name, err)
 	}
 	workflow.File = name
-	if workflow.Name == "" {
-		workflow.Name = name
-	}
+	workflow.Name = workflow.DisplayName(name)
+
+	return NewWorkflowPlannerFromWorkflow(workflow)
+}
 
-	err = validateJobName(workflow)
+// NewWorkflowPlannerFromWorkflow plans an already parsed workflow, e.g. one
+// generated or read from a string with ReadWorkflow, without reading any file
+func NewWorkflowPlannerFromWorkflow(workflow *Workflow) (WorkflowPlanner, error) {
+	err := validateJobName(workflow)
 	if err != nil {
 		return nil, err
 	}
+	warnLint(workflow)
 
-	wp.workflows = append(wp.workflows, workflow)
-
-	return wp, nil
+	return &workflowPlanner{workflows: []*Workflow{workflow}}, nil
 }
 
 func validateJobName(workflow *Workflow) error {
@@ -198,6 +205,12 @@ func validateJobName(workflow *Workflow) error {
 	return nil
 }
 
+func warnLint(workflow *Workflow) {
+	for _, problem := range workflow.Lint() {
+		log.Warnf("workflow '%s': %v", workflow.Name, problem)
+	}
+}
+
 type workflowPlanner struct {
 	workflows []*Workflow
 }
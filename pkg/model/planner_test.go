@@ -56,3 +56,25 @@ func TestWorkflow(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, result)
 }
+
+func TestPlannerWorkflowNames(t *testing.T) {
+	workdir, err := filepath.Abs("testdata/workflow-names")
+	assert.NoError(t, err)
+
+	names := func(wp WorkflowPlanner) map[string]string {
+		names := map[string]string{}
+		for _, w := range wp.(*workflowPlanner).workflows {
+			names[w.File] = w.Name
+		}
+		return names
+	}
+
+	// unnamed workflows are named by their path relative to the workflows dir
+	wp, err := NewWorkflowPlanner(workdir, false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"ci.yml": "CI", "release/publish.yml": "release/publish.yml"}, names(wp))
+
+	wp, err = NewWorkflowPlanner(filepath.Join(workdir, "release", "publish.yml"), false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"publish.yml": "publish.yml"}, names(wp))
+}
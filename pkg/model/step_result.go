@@ -1,6 +1,9 @@
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type stepStatus int
 
@@ -42,4 +45,8 @@ type StepResult struct {
 	Outputs    map[string]string `json:"outputs"`
 	Conclusion stepStatus        `json:"conclusion"`
 	Outcome    stepStatus        `json:"outcome"`
+	// Duration is the wall-clock time the step's main executor ran, it is not part of the steps context
+	Duration time.Duration `json:"-"`
+	// OutputTail holds the last lines of the output of a failed step with Config.StepOutputTail
+	OutputTail []string `json:"-"`
 }
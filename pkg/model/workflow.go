@@ -3,8 +3,10 @@ package model
 import (
 	"fmt"
 	"io"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -66,6 +68,70 @@ func (w *Workflow) OnEvent(event string) interface{} {
 	return nil
 }
 
+// defaultEventTypes are the activity types an event triggers a workflow for when it has no `types` filter
+var defaultEventTypes = map[string][]string{
+	"pull_request":        {"opened", "synchronize", "reopened"},
+	"pull_request_target": {"opened", "synchronize", "reopened"},
+}
+
+// EventTypes returns the activity types of the `on.<event>.types` filter, nil
+// when the event is not filtered by type
+func (w *Workflow) EventTypes(event string) []string {
+	if w.RawOn.Kind != yaml.MappingNode {
+		return nil
+	}
+	var val map[string]yaml.Node
+	if !decodeNode(w.RawOn, &val) {
+		return nil
+	}
+	var filter struct {
+		Types yaml.Node `yaml:"types"`
+	}
+	if n, found := val[event]; !found || n.Kind != yaml.MappingNode || !decodeNode(n, &filter) {
+		return nil
+	}
+	switch filter.Types.Kind {
+	case yaml.ScalarNode:
+		var t string
+		if decodeNode(filter.Types, &t) {
+			return []string{t}
+		}
+	case yaml.SequenceNode:
+		var types []string
+		if decodeNode(filter.Types, &types) {
+			return types
+		}
+	}
+	return nil
+}
+
+// ShouldRunForEvent reports whether the workflow is triggered by the event with
+// the given activity type (the `action` of the event payload). Without a
+// `types` filter the defaults of GitHub apply, an empty activity type matches
+// any filter.
+func (w *Workflow) ShouldRunForEvent(event string, activityType string) bool {
+	if !containsString(w.On(), event) {
+		return false
+	}
+	if activityType == "" {
+		return true
+	}
+	types := w.EventTypes(event)
+	if types == nil {
+		types = defaultEventTypes[event]
+	}
+	return len(types) == 0 || containsString(types, activityType)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 type WorkflowDispatchInput struct {
 	Description string   `yaml:"description"`
 	Required    bool     `yaml:"required"`
@@ -115,6 +181,7 @@ func (w *Workflow) WorkflowDispatchConfig() *WorkflowDispatch {
 	return nil
 }
 
+// WorkflowCallInput is an input a reusable workflow declares under `on.workflow_call.inputs`
 type WorkflowCallInput struct {
 	Description string `yaml:"description"`
 	Required    bool   `yaml:"required"`
@@ -122,20 +189,32 @@ type WorkflowCallInput struct {
 	Type        string `yaml:"type"`
 }
 
+// WorkflowCallOutput is an output of a reusable workflow, its value usually
+// maps a job output like `${{ jobs.build.outputs.version }}`
 type WorkflowCallOutput struct {
 	Description string `yaml:"description"`
 	Value       string `yaml:"value"`
 }
 
+// WorkflowCallSecret is a secret a reusable workflow declares under `on.workflow_call.secrets`
+type WorkflowCallSecret struct {
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+}
+
+// WorkflowCall is the contract of a reusable workflow declared by its `workflow_call` trigger
 type WorkflowCall struct {
 	Inputs  map[string]WorkflowCallInput  `yaml:"inputs"`
 	Outputs map[string]WorkflowCallOutput `yaml:"outputs"`
+	Secrets map[string]WorkflowCallSecret `yaml:"secrets"`
 }
 
 type WorkflowCallResult struct {
 	Outputs map[string]string
 }
 
+// WorkflowCallConfig returns the inputs, outputs and secrets of the `workflow_call`
+// trigger. It is empty but not nil if the trigger declares none.
 func (w *Workflow) WorkflowCallConfig() *WorkflowCall {
 	if w.RawOn.Kind != yaml.MappingNode {
 		// The callers expect for "on: workflow_call" and "on: [ workflow_call ]" a non nil return value
@@ -156,24 +235,379 @@ func (w *Workflow) WorkflowCallConfig() *WorkflowCall {
 	return &config
 }
 
+// Lint returns problems of the workflow which don't prevent it from being planned,
+// but would make it fail once it runs
+func (w *Workflow) Lint() []error {
+	var problems []error
+	if err := validateShell(w.Defaults.Run.Shell); err != nil {
+		problems = append(problems, fmt.Errorf("defaults: %w", err))
+	}
+
+	jobIDs := make([]string, 0, len(w.Jobs))
+	for id := range w.Jobs {
+		jobIDs = append(jobIDs, id)
+	}
+	sort.Strings(jobIDs)
+
+	for _, id := range jobIDs {
+		job := w.Jobs[id]
+		if job == nil {
+			continue
+		}
+		if err := validateShell(job.Defaults.Run.Shell); err != nil {
+			problems = append(problems, fmt.Errorf("job '%s' defaults: %w", id, err))
+		}
+		for i, step := range job.Steps {
+			if step == nil {
+				continue
+			}
+			if err := step.Validate(); err != nil {
+				problems = append(problems, fmt.Errorf("job '%s' step %d (%s): %w", id, i+1, step, err))
+			}
+		}
+		if job.Strategy != nil {
+			for _, err := range lintMatrixTypes(job.Strategy.RawMatrix) {
+				problems = append(problems, fmt.Errorf("job '%s' matrix: %w", id, err))
+			}
+		}
+	}
+	return append(problems, w.lintRunsOnTypos()...)
+}
+
+// defaultRunnerLabels are the runs-on labels of GitHub hosted and self-hosted
+// runners which are always known
+var defaultRunnerLabels = []string{
+	"ubuntu-latest", "ubuntu-24.04", "ubuntu-22.04", "ubuntu-20.04", "ubuntu-24.04-arm", "ubuntu-22.04-arm",
+	"windows-latest", "windows-2025", "windows-2022", "windows-2019", "windows-11-arm",
+	"macos-latest", "macos-15", "macos-14", "macos-13",
+	"macos-latest-large", "macos-15-large", "macos-14-large", "macos-13-large",
+	"macos-latest-xlarge", "macos-15-xlarge", "macos-14-xlarge", "macos-13-xlarge",
+	"self-hosted", "linux", "windows", "macos", "x64", "arm", "arm64",
+}
+
+// lintRunsOnTypos reports runs-on labels of the jobs which are likely typos of
+// a default label, other unknown labels may be those of a self-hosted runner
+func (w *Workflow) lintRunsOnTypos() []error {
+	return w.lintRunsOn(func(label string) (string, bool) {
+		if isKnownRunnerLabel(label, defaultRunnerLabels) {
+			return "", false
+		}
+		suggestion := closestRunnerLabel(label, defaultRunnerLabels)
+		return suggestion, suggestion != ""
+	})
+}
+
+// LintRunsOn reports runs-on labels of the jobs which are neither a default
+// label nor one of knownLabels (e.g. the labels platforms are configured for),
+// suggesting the closest known label. Likely typos of a default label are
+// already reported by Lint and left out.
+func (w *Workflow) LintRunsOn(knownLabels []string) []error {
+	known := append(append([]string{}, defaultRunnerLabels...), knownLabels...)
+	return w.lintRunsOn(func(label string) (string, bool) {
+		if isKnownRunnerLabel(label, known) || closestRunnerLabel(label, defaultRunnerLabels) != "" {
+			return "", false
+		}
+		return closestRunnerLabel(label, knownLabels), true
+	})
+}
+
+// lintRunsOn reports the runs-on labels of the jobs check returns true for,
+// with the suggested label if there is one. Labels of a runner group and
+// expressions aren't checked.
+func (w *Workflow) lintRunsOn(check func(label string) (suggestion string, report bool)) []error {
+	jobIDs := make([]string, 0, len(w.Jobs))
+	for id := range w.Jobs {
+		jobIDs = append(jobIDs, id)
+	}
+	sort.Strings(jobIDs)
+
+	var problems []error
+	for _, id := range jobIDs {
+		if w.Jobs[id] == nil {
+			continue
+		}
+		for _, label := range runsOnLabels(w.Jobs[id]) {
+			if label == "" || strings.Contains(label, "${{") {
+				continue
+			}
+			suggestion, report := check(label)
+			if !report {
+				continue
+			}
+			if suggestion != "" {
+				problems = append(problems, fmt.Errorf("job '%s' runs-on: unknown label '%s', did you mean '%s'?", id, label, suggestion))
+			} else {
+				problems = append(problems, fmt.Errorf("job '%s' runs-on: unknown label '%s'", id, label))
+			}
+		}
+	}
+	return problems
+}
+
+// runsOnLabels returns the runs-on labels of the job as written, without
+// evaluating expressions
+func runsOnLabels(job *Job) []string {
+	if job.RawRunsOn.Kind == yaml.MappingNode {
+		var val struct {
+			Labels yaml.Node
+		}
+		if !decodeNode(job.RawRunsOn, &val) {
+			return nil
+		}
+		return nodeAsStringSlice(val.Labels)
+	}
+	return nodeAsStringSlice(job.RawRunsOn)
+}
+
+func isKnownRunnerLabel(label string, knownLabels []string) bool {
+	for _, known := range knownLabels {
+		if strings.EqualFold(label, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// closestRunnerLabel returns the known label with the smallest edit distance
+// to label, or "" if none is close enough to be a likely typo
+func closestRunnerLabel(label string, knownLabels []string) string {
+	closest := ""
+	closestDistance := len(label)/3 + 1
+	for _, known := range knownLabels {
+		if d := levenshtein(strings.ToLower(label), strings.ToLower(known)); d < closestDistance {
+			closest, closestDistance = known, d
+		}
+	}
+	return closest
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// WorkflowRunTrigger holds the filters of an `on: workflow_run` trigger
+type WorkflowRunTrigger struct {
+	Workflows      []string `yaml:"workflows"`
+	Types          []string `yaml:"types"`
+	Branches       []string `yaml:"branches"`
+	BranchesIgnore []string `yaml:"branches-ignore"`
+}
+
+// WorkflowRunTriggers returns the `workflow_run` triggers of the workflow
+func (w *Workflow) WorkflowRunTriggers() []WorkflowRunTrigger {
+	switch w.RawOn.Kind {
+	case yaml.ScalarNode, yaml.SequenceNode:
+		for _, e := range w.On() {
+			if e == "workflow_run" {
+				return []WorkflowRunTrigger{{}}
+			}
+		}
+	case yaml.MappingNode:
+		var val map[string]yaml.Node
+		if !decodeNode(w.RawOn, &val) {
+			return nil
+		}
+
+		n, found := val["workflow_run"]
+		if !found {
+			return nil
+		}
+		var trigger WorkflowRunTrigger
+		if !decodeNode(n, &trigger) {
+			return nil
+		}
+		return []WorkflowRunTrigger{trigger}
+	}
+	return nil
+}
+
+// Trigger is an event of `on:` together with its filters. Filters the
+// workflow doesn't set are empty.
+type Trigger struct {
+	Event string
+	WorkflowRunTrigger
+	Tags        []string
+	TagsIgnore  []string
+	Paths       []string
+	PathsIgnore []string
+	Cron        []string // cron expressions of a `schedule` trigger
+
+	WorkflowDispatch *WorkflowDispatch // inputs of a `workflow_dispatch` trigger
+	WorkflowCall     *WorkflowCall     // inputs, outputs and secrets of a `workflow_call` trigger
+}
+
+// Triggers returns the triggers of the workflow in the order of `on:`, for
+// each of its scalar, sequence and mapping forms
+func (w *Workflow) Triggers() ([]Trigger, error) {
+	switch w.RawOn.Kind {
+	case 0:
+		return nil, nil
+	case yaml.ScalarNode, yaml.SequenceNode:
+		events := w.On()
+		triggers := make([]Trigger, 0, len(events))
+		for _, event := range events {
+			triggers = append(triggers, Trigger{Event: event})
+		}
+		return triggers, nil
+	case yaml.MappingNode:
+		triggers := make([]Trigger, 0, len(w.RawOn.Content)/2)
+		for i := 0; i+1 < len(w.RawOn.Content); i += 2 {
+			var event string
+			if err := w.RawOn.Content[i].Decode(&event); err != nil {
+				return nil, fmt.Errorf("invalid event in 'on': %w", err)
+			}
+			trigger, err := decodeTrigger(event, w.RawOn.Content[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid 'on.%s': %w", event, err)
+			}
+			triggers = append(triggers, trigger)
+		}
+		return triggers, nil
+	}
+	return nil, fmt.Errorf("invalid 'on' at line %d", w.RawOn.Line)
+}
+
+func decodeTrigger(event string, node *yaml.Node) (Trigger, error) {
+	trigger := Trigger{Event: event}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		// `push:` without filters
+		if node.Tag == "!!null" {
+			return trigger, nil
+		}
+		return trigger, fmt.Errorf("expected a mapping of filters at line %d", node.Line)
+	case yaml.SequenceNode:
+		if event != "schedule" {
+			return trigger, fmt.Errorf("expected a mapping of filters at line %d", node.Line)
+		}
+		var schedules []struct {
+			Cron string `yaml:"cron"`
+		}
+		if err := node.Decode(&schedules); err != nil {
+			return trigger, err
+		}
+		for _, schedule := range schedules {
+			trigger.Cron = append(trigger.Cron, schedule.Cron)
+		}
+		return trigger, nil
+	case yaml.MappingNode:
+	default:
+		return trigger, fmt.Errorf("expected a mapping of filters at line %d", node.Line)
+	}
+
+	var filters struct {
+		Types          yaml.Node `yaml:"types"`
+		Branches       yaml.Node `yaml:"branches"`
+		BranchesIgnore yaml.Node `yaml:"branches-ignore"`
+		Tags           yaml.Node `yaml:"tags"`
+		TagsIgnore     yaml.Node `yaml:"tags-ignore"`
+		Paths          yaml.Node `yaml:"paths"`
+		PathsIgnore    yaml.Node `yaml:"paths-ignore"`
+		Workflows      yaml.Node `yaml:"workflows"`
+	}
+	if err := node.Decode(&filters); err != nil {
+		return trigger, err
+	}
+	for _, f := range []struct {
+		node yaml.Node
+		out  *[]string
+	}{
+		{filters.Types, &trigger.Types},
+		{filters.Branches, &trigger.Branches},
+		{filters.BranchesIgnore, &trigger.BranchesIgnore},
+		{filters.Tags, &trigger.Tags},
+		{filters.TagsIgnore, &trigger.TagsIgnore},
+		{filters.Paths, &trigger.Paths},
+		{filters.PathsIgnore, &trigger.PathsIgnore},
+		{filters.Workflows, &trigger.Workflows},
+	} {
+		if f.node.Kind == yaml.MappingNode {
+			return trigger, fmt.Errorf("expected a string or a list of strings at line %d", f.node.Line)
+		}
+		*f.out = nodeAsStringSlice(f.node)
+	}
+
+	switch event {
+	case "workflow_dispatch":
+		trigger.WorkflowDispatch = &WorkflowDispatch{}
+		if err := node.Decode(trigger.WorkflowDispatch); err != nil {
+			return trigger, err
+		}
+	case "workflow_call":
+		trigger.WorkflowCall = &WorkflowCall{}
+		if err := node.Decode(trigger.WorkflowCall); err != nil {
+			return trigger, err
+		}
+	}
+	return trigger, nil
+}
+
 // Job is the structure of one job in a workflow
 type Job struct {
-	Name           string                    `yaml:"name"`
-	RawNeeds       yaml.Node                 `yaml:"needs"`
-	RawRunsOn      yaml.Node                 `yaml:"runs-on"`
-	Env            yaml.Node                 `yaml:"env"`
-	If             yaml.Node                 `yaml:"if"`
-	Steps          []*Step                   `yaml:"steps"`
-	TimeoutMinutes string                    `yaml:"timeout-minutes"`
-	Services       map[string]*ContainerSpec `yaml:"services"`
-	Strategy       *Strategy                 `yaml:"strategy"`
-	RawContainer   yaml.Node                 `yaml:"container"`
-	Defaults       Defaults                  `yaml:"defaults"`
-	Outputs        map[string]string         `yaml:"outputs"`
-	Uses           string                    `yaml:"uses"`
-	With           map[string]interface{}    `yaml:"with"`
-	RawSecrets     yaml.Node                 `yaml:"secrets"`
-	Result         string
+	Name               string                    `yaml:"name"`
+	RawNeeds           yaml.Node                 `yaml:"needs"`
+	RawRunsOn          yaml.Node                 `yaml:"runs-on"`
+	Env                yaml.Node                 `yaml:"env"`
+	If                 yaml.Node                 `yaml:"if"`
+	Steps              []*Step                   `yaml:"steps"`
+	TimeoutMinutes     string                    `yaml:"timeout-minutes"`
+	Services           map[string]*ContainerSpec `yaml:"services"`
+	Strategy           *Strategy                 `yaml:"strategy"`
+	RawContainer       yaml.Node                 `yaml:"container"`
+	Defaults           Defaults                  `yaml:"defaults"`
+	Outputs            map[string]string         `yaml:"outputs"`
+	Uses               string                    `yaml:"uses"`
+	With               map[string]interface{}    `yaml:"with"`
+	RawSecrets         yaml.Node                 `yaml:"secrets"`
+	RawContinueOnError string                    `yaml:"continue-on-error"`
+	RawEnvironment     yaml.Node                 `yaml:"environment"`
+	Result             string
+	FailureAllowed     bool `yaml:"-"` // the job failed with continue-on-error, its failure doesn't fail the run
+}
+
+// JobEnvironment is the deployment environment referenced by a job
+type JobEnvironment struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// DeploymentEnvironment returns the deployment environment of the job, or nil if it has none
+func (j *Job) DeploymentEnvironment() *JobEnvironment {
+	var val *JobEnvironment
+	switch j.RawEnvironment.Kind {
+	case yaml.ScalarNode:
+		val = new(JobEnvironment)
+		if !decodeNode(j.RawEnvironment, &val.Name) {
+			return nil
+		}
+	case yaml.MappingNode:
+		val = new(JobEnvironment)
+		if !decodeNode(j.RawEnvironment, val) {
+			return nil
+		}
+	}
+	return val
+}
+
+// ContinueOnError returns the unevaluated `continue-on-error` expression of the job
+func (j *Job) ContinueOnError() string {
+	return j.RawContinueOnError
 }
 
 // Strategy for the job
@@ -183,6 +617,9 @@ type Strategy struct {
 	FailFastString    string    `yaml:"fail-fast"`
 	MaxParallelString string    `yaml:"max-parallel"`
 	RawMatrix         yaml.Node `yaml:"matrix"`
+
+	// DefaultMaxParallel is used when the workflow doesn't set `max-parallel`, 0 means 4
+	DefaultMaxParallel int `yaml:"-"`
 }
 
 // Default settings that will apply to all steps in the job or workflow
@@ -203,7 +640,11 @@ func (s Strategy) GetMaxParallel() int {
 	// 1: tl;dr: self-hosted does only 1 parallel job - https://github.com/actions/runner/issues/639#issuecomment-825212735
 	// 2: GH has 20 parallel job limit (for free tier) - https://github.com/github/docs/blob/3ae84420bd10997bb5f35f629ebb7160fe776eae/content/actions/reference/usage-limits-billing-and-administration.md?plain=1#L45
 	// 3: I want to add support for MaxParallel to act and 20! parallel jobs is a bit overkill IMHO
+	// The default can be changed with DefaultMaxParallel.
 	maxParallel := 4
+	if s.DefaultMaxParallel > 0 {
+		maxParallel = s.DefaultMaxParallel
+	}
 	if s.MaxParallelString != "" {
 		var err error
 		if maxParallel, err = strconv.Atoi(s.MaxParallelString); err != nil {
@@ -227,6 +668,8 @@ func (s Strategy) GetFailFast() bool {
 	return failFast
 }
 
+// InheritSecrets reports whether a job calling a reusable workflow passes all
+// of its secrets with `secrets: inherit`
 func (j *Job) InheritSecrets() bool {
 	if j.RawSecrets.Kind != yaml.ScalarNode {
 		return false
@@ -240,6 +683,8 @@ func (j *Job) InheritSecrets() bool {
 	return val == "inherit"
 }
 
+// Secrets returns the secrets explicitly mapped to a reusable workflow, it is
+// nil for `secrets: inherit`
 func (j *Job) Secrets() map[string]string {
 	if j.RawSecrets.Kind != yaml.MappingNode {
 		return nil
@@ -362,6 +807,9 @@ func (j *Job) Matrix() map[string][]interface{} {
 
 // GetMatrixes returns the matrix cross product
 // It skips includes and hard fails excludes for non-existing keys
+// The combinations are ordered by the sorted keys of the matrix, the values of
+// the last key vary fastest, followed by the extra includes. So the order and
+// with it `strategy.job-index` are the same for every run.
 //
 //nolint:gocyclo
 func (j *Job) GetMatrixes() ([]map[string]interface{}, error) {
@@ -370,6 +818,10 @@ func (j *Job) GetMatrixes() ([]map[string]interface{}, error) {
 		j.Strategy.FailFast = j.Strategy.GetFailFast()
 		j.Strategy.MaxParallel = j.Strategy.GetMaxParallel()
 
+		if err := validateMatrixEntries(j.Strategy.RawMatrix); err != nil {
+			return nil, err
+		}
+
 		if m := j.Matrix(); m != nil {
 			includes := make([]map[string]interface{}, 0)
 			extraIncludes := make([]map[string]interface{}, 0)
@@ -377,7 +829,10 @@ func (j *Job) GetMatrixes() ([]map[string]interface{}, error) {
 				switch t := v.(type) {
 				case []interface{}:
 					for _, i := range t {
-						i := i.(map[string]interface{})
+						i, ok := i.(map[string]interface{})
+						if !ok {
+							return nil, fmt.Errorf("the workflow is not valid. Matrix include entry %v is not a map", v)
+						}
 						extraInclude := true
 						for k := range i {
 							if _, ok := m[k]; ok {
@@ -391,7 +846,10 @@ func (j *Job) GetMatrixes() ([]map[string]interface{}, error) {
 						}
 					}
 				case interface{}:
-					v := v.(map[string]interface{})
+					v, ok := v.(map[string]interface{})
+					if !ok {
+						return nil, fmt.Errorf("the workflow is not valid. Matrix include entry %v is not a map", t)
+					}
 					extraInclude := true
 					for k := range v {
 						if _, ok := m[k]; ok {
@@ -408,8 +866,11 @@ func (j *Job) GetMatrixes() ([]map[string]interface{}, error) {
 			delete(m, "include")
 
 			excludes := make([]map[string]interface{}, 0)
-			for _, e := range m["exclude"] {
-				e := e.(map[string]interface{})
+			for _, v := range m["exclude"] {
+				e, ok := v.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("the workflow is not valid. Matrix exclude entry %v is not a map", v)
+				}
 				for k := range e {
 					if _, ok := m[k]; ok {
 						excludes = append(excludes, e)
@@ -464,6 +925,114 @@ func (j *Job) GetMatrixes() ([]map[string]interface{}, error) {
 	return matrixes, nil
 }
 
+// validateMatrixEntries checks that `include` and `exclude` of a matrix are lists of maps,
+// which can't be expressed by decoding the matrix
+func validateMatrixEntries(rawMatrix yaml.Node) error {
+	if rawMatrix.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(rawMatrix.Content); i += 2 {
+		key, value := rawMatrix.Content[i].Value, rawMatrix.Content[i+1]
+		if key != "include" && key != "exclude" {
+			continue
+		}
+		if value.Kind == yaml.ScalarNode || value.Kind == yaml.MappingNode {
+			return fmt.Errorf("the workflow is not valid. Matrix %s must be a list of maps, got %q (line %d)", key, value.Value, value.Line)
+		}
+		for _, entry := range value.Content {
+			if entry.Kind == yaml.ScalarNode {
+				return fmt.Errorf("the workflow is not valid. Matrix %s entry %q is not a map (line %d)", key, entry.Value, entry.Line)
+			}
+		}
+	}
+	return nil
+}
+
+// lintMatrixTypes reports matrix dimensions mixing values of different types, including
+// values added by `include`, as the entries of such dimensions won't match each other
+func lintMatrixTypes(rawMatrix yaml.Node) []error {
+	if rawMatrix.Kind != yaml.MappingNode || validateMatrixEntries(rawMatrix) != nil {
+		return nil
+	}
+	// expressions aren't evaluated yet, so silently skip what doesn't decode
+	var matrix map[string]interface{}
+	if err := rawMatrix.Decode(&matrix); err != nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(matrix))
+	for k := range matrix {
+		if k != "include" && k != "exclude" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var problems []error
+	dimensionTypes := map[string]string{}
+	for _, k := range keys {
+		values, ok := matrix[k].([]interface{})
+		if !ok {
+			continue
+		}
+		types := map[string]bool{}
+		for _, v := range values {
+			types[matrixValueType(v)] = true
+		}
+		if len(types) > 1 {
+			problems = append(problems, fmt.Errorf("dimension '%s' mixes values of type %s", k, strings.Join(sortedKeys(types), ", ")))
+			continue
+		}
+		for t := range types {
+			dimensionTypes[k] = t
+		}
+	}
+
+	includes, _ := matrix["include"].([]interface{})
+	for _, include := range includes {
+		entry, ok := include.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, k := range keys {
+			v, ok := entry[k]
+			if !ok || dimensionTypes[k] == "" {
+				continue
+			}
+			if t := matrixValueType(v); t != dimensionTypes[k] {
+				problems = append(problems, fmt.Errorf("include sets '%s' to %v of type %s, but the dimension holds values of type %s", k, v, t, dimensionTypes[k]))
+			}
+		}
+	}
+	return problems
+}
+
+func matrixValueType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64, float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func commonKeysMatch(a map[string]interface{}, b map[string]interface{}) bool {
 	for aKey, aVal := range a {
 		if bVal, ok := b[aKey]; ok && !reflect.DeepEqual(aVal, bVal) {
@@ -549,6 +1118,9 @@ type ContainerSpec struct {
 	Args        string
 	Name        string
 	Reuse       bool
+
+	// ContinueOnError lets the job run without the service if it fails to start
+	ContinueOnError bool `yaml:"continue-on-error"`
 }
 
 // Step is the structure of one step in a job
@@ -619,6 +1191,30 @@ func (s *Step) ShellCommand() string {
 	return shellCommand
 }
 
+// Validate checks the step for settings which can't be run
+func (s *Step) Validate() error {
+	if s.Type() != StepTypeRun {
+		return nil
+	}
+	return validateShell(s.Shell)
+}
+
+var builtinShells = []string{"bash", "pwsh", "python", "sh", "cmd", "powershell"}
+
+// validateShell accepts the shells known to ShellCommand and custom shells
+// which reference the script with '{0}'
+func validateShell(shell string) error {
+	if shell == "" || strings.Contains(shell, "{0}") || strings.Contains(shell, "${{") {
+		return nil
+	}
+	for _, builtin := range builtinShells {
+		if shell == builtin {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported shell '%s', use one of %s or a custom shell containing '{0}'", shell, strings.Join(builtinShells, ", "))
+}
+
 // StepType describes what type of step we are about to run
 type StepType int
 
@@ -695,6 +1291,19 @@ func ReadWorkflow(in io.Reader) (*Workflow, error) {
 	return w, err
 }
 
+// DisplayName returns the name of the workflow. Like GitHub it falls back to the
+// path of the workflow file, relative to .github/workflows, if the name is empty.
+func (w *Workflow) DisplayName(path string) string {
+	if w.Name != "" {
+		return w.Name
+	}
+	path = filepath.ToSlash(path)
+	if _, rel, ok := strings.Cut(path, ".github/workflows/"); ok {
+		return rel
+	}
+	return path
+}
+
 // GetJob will get a job by name in the workflow
 func (w *Workflow) GetJob(jobID string) *Job {
 	for id, j := range w.Jobs {
@@ -720,6 +1329,28 @@ func (w *Workflow) GetJobIDs() []string {
 	return ids
 }
 
+// JobsForEvent returns the ids of all jobs to run when the workflow is triggered by eventName
+// It returns an empty list if the workflow isn't triggered by the event
+func (w *Workflow) JobsForEvent(eventName string) ([]string, error) {
+	ids := make([]string, 0)
+	for _, e := range w.On() {
+		if e == eventName {
+			ids = w.GetJobIDs()
+			break
+		}
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	// make sure the needs graph of the jobs can be resolved
+	if _, err := createStages(w, ids...); err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
 var OnDecodeNodeError = func(node yaml.Node, out interface{}, err error) {
 	log.Fatalf("Failed to decode node %v into %T: %v", node, out, err)
 }
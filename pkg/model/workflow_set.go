@@ -0,0 +1,122 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WorkflowSet aggregates all loaded workflows, e.g. all files in .github/workflows
+type WorkflowSet struct {
+	Workflows []*Workflow
+}
+
+// NewWorkflowSet creates a WorkflowSet of the given workflows
+func NewWorkflowSet(workflows ...*Workflow) *WorkflowSet {
+	return &WorkflowSet{
+		Workflows: workflows,
+	}
+}
+
+// FindJob returns the job with the given id and the workflow defining it
+// It fails if no workflow or more than one workflow defines the job
+func (ws *WorkflowSet) FindJob(jobID string) (*Workflow, *Job, error) {
+	var workflow *Workflow
+	var job *Job
+	files := make([]string, 0)
+	for _, w := range ws.Workflows {
+		if j := w.GetJob(jobID); j != nil {
+			workflow = w
+			job = j
+			files = append(files, w.File)
+		}
+	}
+
+	switch len(files) {
+	case 0:
+		return nil, nil, fmt.Errorf("job '%s' not found in any workflow", jobID)
+	case 1:
+		return workflow, job, nil
+	}
+	return nil, nil, fmt.Errorf("job '%s' is ambiguous, it is defined in multiple workflows: %s", jobID, strings.Join(files, ", "))
+}
+
+// workflowFile returns the path of a local reusable workflow relative to
+// .github/workflows, the File of the workflow loaded from it
+func workflowFile(uses string) string {
+	if _, rel, ok := strings.Cut(uses, ".github/workflows/"); ok {
+		return rel
+	}
+	return path.Base(uses)
+}
+
+// findWorkflow returns the workflow loaded from the given file, or nil
+func (ws *WorkflowSet) findWorkflow(file string) *Workflow {
+	for _, w := range ws.Workflows {
+		if w.File == file {
+			return w
+		}
+	}
+	return nil
+}
+
+// Validate checks that every job calling a local reusable workflow of the set
+// supplies the inputs and secrets the called workflow requires.
+// Inputs and secrets the called workflow doesn't declare are logged as warnings.
+// Remote reusable workflows aren't part of the set and are not checked.
+func (ws *WorkflowSet) Validate() error {
+	var errs []error
+	for _, caller := range ws.Workflows {
+		for _, id := range sortedKeys(caller.Jobs) {
+			job := caller.Jobs[id]
+			if job == nil {
+				continue
+			}
+			if jobType, err := job.Type(); err != nil || jobType != JobTypeReusableWorkflowLocal {
+				continue
+			}
+			called := ws.findWorkflow(workflowFile(job.Uses))
+			if called == nil {
+				continue
+			}
+			errs = append(errs, validateWorkflowCall(caller.File, id, job, called.WorkflowCallConfig())...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateWorkflowCall(file string, jobID string, job *Job, config *WorkflowCall) []error {
+	var errs []error
+
+	for _, name := range sortedKeys(config.Inputs) {
+		input := config.Inputs[name]
+		if _, ok := job.With[name]; !ok && input.Required && input.Default == "" {
+			errs = append(errs, fmt.Errorf("%s: job '%s' calls '%s' without the required input '%s'", file, jobID, job.Uses, name))
+		}
+	}
+	for _, name := range sortedKeys(job.With) {
+		if _, ok := config.Inputs[name]; !ok {
+			log.Warnf("%s: job '%s' passes the input '%s' which is not declared by '%s'", file, jobID, name, job.Uses)
+		}
+	}
+
+	if job.InheritSecrets() {
+		return errs
+	}
+	secrets := job.Secrets()
+	for _, name := range sortedKeys(config.Secrets) {
+		if _, ok := secrets[name]; !ok && config.Secrets[name].Required {
+			errs = append(errs, fmt.Errorf("%s: job '%s' calls '%s' without the required secret '%s'", file, jobID, job.Uses, name))
+		}
+	}
+	for _, name := range sortedKeys(secrets) {
+		if _, ok := config.Secrets[name]; !ok {
+			log.Warnf("%s: job '%s' passes the secret '%s' which is not declared by '%s'", file, jobID, name, job.Uses)
+		}
+	}
+
+	return errs
+}
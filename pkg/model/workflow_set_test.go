@@ -0,0 +1,121 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readTestWorkflow(t *testing.T, file string, yaml string) *Workflow {
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+	workflow.File = file
+	return workflow
+}
+
+func TestWorkflowSet_FindJob(t *testing.T) {
+	build := readTestWorkflow(t, "build.yml", `
+name: build
+on: push
+
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo build
+  test:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo test
+`)
+	release := readTestWorkflow(t, "release.yml", `
+name: release
+on: push
+
+jobs:
+  release:
+    runs-on: ubuntu-latest
+    needs: test
+    steps:
+    - run: echo release
+  test:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo test
+`)
+	ws := NewWorkflowSet(build, release)
+
+	workflow, job, err := ws.FindJob("release")
+	assert.NoError(t, err)
+	assert.Equal(t, release, workflow)
+	assert.Equal(t, []string{"test"}, job.Needs())
+
+	_, _, err = ws.FindJob("test")
+	assert.EqualError(t, err, "job 'test' is ambiguous, it is defined in multiple workflows: build.yml, release.yml")
+
+	_, _, err = ws.FindJob("deploy")
+	assert.EqualError(t, err, "job 'deploy' not found in any workflow")
+}
+
+func TestWorkflowSet_Validate(t *testing.T) {
+	called := readTestWorkflow(t, "deploy.yml", `
+name: deploy
+on:
+  workflow_call:
+    inputs:
+      environment:
+        required: true
+        type: string
+      dry-run:
+        required: true
+        type: boolean
+        default: false
+    secrets:
+      token:
+        required: true
+
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo deploy
+`)
+	caller := readTestWorkflow(t, "release.yml", `
+name: release
+on: push
+
+jobs:
+  complete:
+    uses: ./.github/workflows/deploy.yml
+    with:
+      environment: production
+    secrets:
+      token: ${{ secrets.TOKEN }}
+  inherit:
+    uses: ./.github/workflows/deploy.yml
+    with:
+      environment: production
+    secrets: inherit
+  missing:
+    uses: ./.github/workflows/deploy.yml
+    with:
+      unknown: value
+  remote:
+    uses: octo-org/repo/.github/workflows/deploy.yml@v1
+`)
+
+	err := NewWorkflowSet(caller, called).Validate()
+	assert.EqualError(t, err, strings.Join([]string{
+		"release.yml: job 'missing' calls './.github/workflows/deploy.yml' without the required input 'environment'",
+		"release.yml: job 'missing' calls './.github/workflows/deploy.yml' without the required secret 'token'",
+	}, "\n"))
+
+	assert.NoError(t, NewWorkflowSet(called).Validate())
+
+	// workflows in sub directories are found by their path relative to .github/workflows
+	called.File = "deploy/prod.yml"
+	caller.Jobs["missing"].Uses = "./.github/workflows/deploy/prod.yml"
+	err = NewWorkflowSet(caller, called).Validate()
+	assert.ErrorContains(t, err, "release.yml: job 'missing' calls './.github/workflows/deploy/prod.yml' without the required input 'environment'")
+}
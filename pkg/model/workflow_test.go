@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -173,6 +174,66 @@ jobs:
 	})
 }
 
+func TestReadWorkflow_JobEnvironment(t *testing.T) {
+	yaml := `
+name: deploy
+
+jobs:
+  scalar:
+    environment: production
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+  mapping:
+    environment:
+      name: staging
+      url: ${{ steps.deploy.outputs.url }}
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+  none:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+`
+
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	assert.Equal(t, &JobEnvironment{Name: "production"}, workflow.GetJob("scalar").DeploymentEnvironment())
+	assert.Equal(t, &JobEnvironment{Name: "staging", URL: "${{ steps.deploy.outputs.url }}"}, workflow.GetJob("mapping").DeploymentEnvironment())
+	assert.Nil(t, workflow.GetJob("none").DeploymentEnvironment())
+}
+
+func TestReadWorkflow_JobSecrets(t *testing.T) {
+	yaml := `
+name: reusable
+
+jobs:
+  inherit:
+    uses: ./.github/workflows/reusable.yml
+    secrets: inherit
+  explicit:
+    uses: ./.github/workflows/reusable.yml
+    secrets:
+      token: ${{ secrets.TOKEN }}
+  none:
+    uses: ./.github/workflows/reusable.yml
+`
+
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	assert.True(t, workflow.GetJob("inherit").InheritSecrets())
+	assert.Nil(t, workflow.GetJob("inherit").Secrets())
+
+	assert.False(t, workflow.GetJob("explicit").InheritSecrets())
+	assert.Equal(t, map[string]string{"token": "${{ secrets.TOKEN }}"}, workflow.GetJob("explicit").Secrets())
+
+	assert.False(t, workflow.GetJob("none").InheritSecrets())
+	assert.Nil(t, workflow.GetJob("none").Secrets())
+}
+
 func TestReadWorkflow_JobTypes(t *testing.T) {
 	yaml := `
 name: invalid job definition
@@ -401,6 +462,12 @@ func TestReadWorkflow_Strategy(t *testing.T) {
 	assert.Equal(t, job.Strategy.FailFast, false)
 }
 
+func TestStrategy_DefaultMaxParallel(t *testing.T) {
+	assert.Equal(t, 4, Strategy{}.GetMaxParallel())
+	assert.Equal(t, 10, Strategy{DefaultMaxParallel: 10}.GetMaxParallel())
+	assert.Equal(t, 2, Strategy{DefaultMaxParallel: 10, MaxParallelString: "2"}.GetMaxParallel())
+}
+
 func TestStep_ShellCommand(t *testing.T) {
 	tests := []struct {
 		shell string
@@ -521,3 +588,546 @@ func TestReadWorkflow_WorkflowDispatchConfig(t *testing.T) {
 		Type:     "choice",
 	}, workflowDispatch.Inputs["logLevel"])
 }
+
+func TestReadWorkflow_WorkflowRunTriggers(t *testing.T) {
+	yaml := `
+name: local-action-docker-url
+on: push
+`
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+	assert.Nil(t, workflow.WorkflowRunTriggers())
+
+	yaml = `
+name: local-action-docker-url
+on:
+  push:
+  workflow_run:
+    workflows: [build, "integration tests"]
+    types: [completed]
+    branches:
+    - main
+`
+	workflow, err = ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+	assert.Equal(t, []WorkflowRunTrigger{
+		{
+			Workflows: []string{"build", "integration tests"},
+			Types:     []string{"completed"},
+			Branches:  []string{"main"},
+		},
+	}, workflow.WorkflowRunTriggers())
+}
+
+func TestWorkflow_JobsForEvent(t *testing.T) {
+	yaml := `
+name: jobs-for-event
+on: [push, pull_request]
+
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo build
+  test:
+    runs-on: ubuntu-latest
+    needs: build
+    steps:
+    - run: echo test
+`
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	jobs, err := workflow.JobsForEvent("push")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build", "test"}, jobs)
+
+	jobs, err = workflow.JobsForEvent("workflow_dispatch")
+	assert.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestWorkflow_JobsForEventInvalidNeeds(t *testing.T) {
+	yaml := `
+name: jobs-for-event
+on: push
+
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    needs: test
+    steps:
+    - run: echo build
+  test:
+    runs-on: ubuntu-latest
+    needs: build
+    steps:
+    - run: echo test
+`
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	_, err = workflow.JobsForEvent("push")
+	assert.Error(t, err)
+}
+
+func TestStep_Validate(t *testing.T) {
+	tests := []struct {
+		shell string
+		valid bool
+	}{
+		{"", true},
+		{"bash", true},
+		{"pwsh", true},
+		{"perl -e {0}", true},
+		{"${{ matrix.shell }}", true},
+		{"bashh", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			step := &Step{Run: "echo hi", Shell: tt.shell}
+			if tt.valid {
+				assert.NoError(t, step.Validate())
+			} else {
+				assert.Error(t, step.Validate())
+			}
+		})
+	}
+
+	// only run steps use a shell
+	assert.NoError(t, (&Step{Uses: "actions/checkout@v3", Shell: "bashh"}).Validate())
+}
+
+func TestWorkflow_Lint(t *testing.T) {
+	yaml := `
+name: lint
+on: push
+
+defaults:
+  run:
+    shell: zsh
+
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo build
+      shell: bashh
+    - run: echo custom
+      shell: perl -e {0}
+`
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	problems := workflow.Lint()
+	if assert.Len(t, problems, 2) {
+		assert.Contains(t, problems[0].Error(), "defaults: unsupported shell 'zsh'")
+		assert.Contains(t, problems[1].Error(), "job 'build' step 1 (echo build): unsupported shell 'bashh'")
+	}
+}
+
+func TestJob_GetMatrixesMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix string
+		err    string
+	}{
+		{
+			name: "exclude scalar",
+			matrix: `
+os: [ubuntu, windows]
+exclude: ubuntu`,
+			err: `Matrix exclude must be a list of maps, got "ubuntu"`,
+		},
+		{
+			name: "exclude scalar entry",
+			matrix: `
+os: [ubuntu, windows]
+exclude: [ubuntu]`,
+			err: `Matrix exclude entry "ubuntu" is not a map`,
+		},
+		{
+			name: "include scalar entry",
+			matrix: `
+os: [ubuntu, windows]
+include:
+  - os: macos
+  - macos`,
+			err: `Matrix include entry "macos" is not a map`,
+		},
+		{
+			name: "include nested scalar entry",
+			matrix: `
+os: [ubuntu, windows]
+include:
+  - [macos]`,
+			err: `Matrix include entry [macos] is not a map`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow, err := ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:` + strings.ReplaceAll(tt.matrix, "\n", "\n        ") + `
+    steps:
+    - run: echo test
+`))
+			assert.NoError(t, err, "read workflow should succeed")
+
+			matrixes, err := workflow.Jobs["test"].GetMatrixes()
+			assert.Nil(t, matrixes)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestWorkflow_LintMatrixTypes(t *testing.T) {
+	yaml := `
+name: lint-matrix
+on: push
+
+jobs:
+  mixed:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        node: [12, 14]
+        os: [ubuntu, 1]
+        include:
+          - node: '16'
+          - node: 18
+    steps:
+    - run: echo mixed
+  consistent:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        node: [12, 14]
+        include:
+          - node: 16
+            experimental: true
+    steps:
+    - run: echo consistent
+  expression:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        node: ${{ fromJSON('[12, 14]') }}
+    steps:
+    - run: echo expression
+`
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	problems := workflow.Lint()
+	if assert.Len(t, problems, 2) {
+		assert.EqualError(t, problems[0], "job 'mixed' matrix: dimension 'os' mixes values of type number, string")
+		assert.EqualError(t, problems[1], "job 'mixed' matrix: include sets 'node' to 16 of type string, but the dimension holds values of type number")
+	}
+}
+
+func TestWorkflow_LintRunsOn(t *testing.T) {
+	yaml := `
+name: lint-runs-on
+on: push
+
+jobs:
+  typo:
+    runs-on: ubunto-latest
+    steps:
+    - run: echo typo
+  version-typo:
+    runs-on: [self-hosted, windws-2022]
+    steps:
+    - run: echo version
+  unknown:
+    runs-on: gpu-cluster
+    steps:
+    - run: echo unknown
+  valid:
+    runs-on: [self-hosted, linux, x64, ubuntu-22.04]
+    steps:
+    - run: echo valid
+  group:
+    runs-on:
+      group: my-runners
+      labels: [macos-14]
+    steps:
+    - run: echo group
+  expression:
+    runs-on: ${{ matrix.os }}
+    steps:
+    - run: echo expression
+`
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	problems := workflow.Lint()
+	if assert.Len(t, problems, 2) {
+		assert.EqualError(t, problems[0], "job 'typo' runs-on: unknown label 'ubunto-latest', did you mean 'ubuntu-latest'?")
+		assert.EqualError(t, problems[1], "job 'version-typo' runs-on: unknown label 'windws-2022', did you mean 'windows-2022'?")
+	}
+
+	problems = workflow.LintRunsOn(nil)
+	if assert.Len(t, problems, 1) {
+		assert.EqualError(t, problems[0], "job 'unknown' runs-on: unknown label 'gpu-cluster'")
+	}
+	assert.Empty(t, workflow.LintRunsOn([]string{"gpu-cluster"}))
+
+	problems = workflow.LintRunsOn([]string{"gpu-clusters"})
+	if assert.Len(t, problems, 1) {
+		assert.EqualError(t, problems[0], "job 'unknown' runs-on: unknown label 'gpu-cluster', did you mean 'gpu-clusters'?")
+	}
+}
+
+func TestWorkflow_LintRunsOnVersions(t *testing.T) {
+	for label, problem := range map[string]string{
+		"ubuntu-22.04":  "",
+		"UBUNTU-LATEST": "",
+		"macos-14":      "",
+		"ubuntu-lates":  "unknown label 'ubuntu-lates', did you mean 'ubuntu-latest'?",
+		"ubuntu-2204":   "unknown label 'ubuntu-2204', did you mean 'ubuntu-22.04'?",
+		"windows-latst": "unknown label 'windows-latst', did you mean 'windows-latest'?",
+	} {
+		workflow, err := ReadWorkflow(strings.NewReader(fmt.Sprintf("on: push\njobs:\n  build:\n    runs-on: %s\n    steps:\n    - run: echo\n", label)))
+		assert.NoError(t, err)
+		problems := workflow.Lint()
+		if problem == "" {
+			assert.Empty(t, problems, label)
+		} else if assert.Len(t, problems, 1, label) {
+			assert.EqualError(t, problems[0], "job 'build' runs-on: "+problem)
+		}
+	}
+}
+
+func TestWorkflow_DisplayName(t *testing.T) {
+	named := &Workflow{Name: "CI"}
+	assert.Equal(t, "CI", named.DisplayName(".github/workflows/ci.yml"))
+
+	unnamed := &Workflow{}
+	assert.Equal(t, "ci.yml", unnamed.DisplayName(".github/workflows/ci.yml"))
+	assert.Equal(t, "ci.yml", unnamed.DisplayName("/home/user/repo/.github/workflows/ci.yml"))
+	assert.Equal(t, "release/deploy.yaml", unnamed.DisplayName(".github/workflows/release/deploy.yaml"))
+	assert.Equal(t, "workflow.yml", unnamed.DisplayName("workflow.yml"))
+}
+
+func TestWorkflow_ShouldRunForEvent(t *testing.T) {
+	workflow, err := ReadWorkflow(strings.NewReader(`
+name: types
+on:
+  pull_request:
+    types: [opened]
+  pull_request_target:
+  issues:
+    types: labeled
+  push:
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+`))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	assert.Equal(t, []string{"opened"}, workflow.EventTypes("pull_request"))
+	assert.Equal(t, []string{"labeled"}, workflow.EventTypes("issues"))
+	assert.Nil(t, workflow.EventTypes("push"))
+
+	assert.True(t, workflow.ShouldRunForEvent("pull_request", "opened"))
+	assert.False(t, workflow.ShouldRunForEvent("pull_request", "closed"))
+	assert.True(t, workflow.ShouldRunForEvent("pull_request", ""))
+	assert.True(t, workflow.ShouldRunForEvent("issues", "labeled"))
+	assert.False(t, workflow.ShouldRunForEvent("issues", "opened"))
+
+	// without types the defaults of GitHub apply
+	assert.True(t, workflow.ShouldRunForEvent("pull_request_target", "synchronize"))
+	assert.False(t, workflow.ShouldRunForEvent("pull_request_target", "closed"))
+	assert.True(t, workflow.ShouldRunForEvent("push", "anything"))
+
+	assert.False(t, workflow.ShouldRunForEvent("workflow_dispatch", ""))
+}
+
+func TestReadWorkflow_WorkflowCall(t *testing.T) {
+	workflow, err := ReadWorkflow(strings.NewReader(`
+name: reusable
+on:
+  workflow_call:
+    inputs:
+      environment:
+        description: target environment
+        required: true
+        type: string
+      dry-run:
+        type: boolean
+        default: false
+      retries:
+        type: number
+        default: 3
+    outputs:
+      version:
+        description: the built version
+        value: ${{ jobs.build.outputs.version }}
+    secrets:
+      token:
+        required: true
+      optional-token:
+        description: used when set
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    outputs:
+      version: ${{ steps.v.outputs.version }}
+    steps:
+      - id: v
+        run: echo "version=1.0" >> $GITHUB_OUTPUT
+`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, &WorkflowCall{
+		Inputs: map[string]WorkflowCallInput{
+			"environment": {Description: "target environment", Required: true, Type: "string"},
+			"dry-run":     {Default: "false", Type: "boolean"},
+			"retries":     {Default: "3", Type: "number"},
+		},
+		Outputs: map[string]WorkflowCallOutput{
+			"version": {Description: "the built version", Value: "${{ jobs.build.outputs.version }}"},
+		},
+		Secrets: map[string]WorkflowCallSecret{
+			"token":          {Required: true},
+			"optional-token": {Description: "used when set"},
+		},
+	}, workflow.WorkflowCallConfig())
+
+	for _, on := range []string{"workflow_call", "[push, workflow_call]"} {
+		workflow, err = ReadWorkflow(strings.NewReader("on: " + on + `
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+`))
+		assert.NoError(t, err)
+		assert.Equal(t, &WorkflowCall{}, workflow.WorkflowCallConfig(), on)
+	}
+}
+
+func TestReadWorkflow_MatrixOrder(t *testing.T) {
+	workflow, err := ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        os: [ubuntu, windows, macos]
+        node: [18, 20]
+        include:
+          - experimental: true
+    steps:
+      - run: echo
+`))
+	assert.NoError(t, err)
+
+	first, err := workflow.GetJob("test").GetMatrixes()
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"node": 18, "os": "ubuntu"},
+		{"node": 18, "os": "windows"},
+		{"node": 18, "os": "macos"},
+		{"node": 20, "os": "ubuntu"},
+		{"node": 20, "os": "windows"},
+		{"node": 20, "os": "macos"},
+		{"experimental": true},
+	}, first)
+
+	for i := 0; i < 20; i++ {
+		matrixes, err := workflow.GetJob("test").GetMatrixes()
+		assert.NoError(t, err)
+		assert.Equal(t, first, matrixes)
+	}
+}
+
+func TestWorkflow_Triggers(t *testing.T) {
+	t.Run("scalar", func(t *testing.T) {
+		workflow, err := ReadWorkflow(strings.NewReader("on: push\njobs: {}\n"))
+		assert.NoError(t, err)
+		triggers, err := workflow.Triggers()
+		assert.NoError(t, err)
+		assert.Equal(t, []Trigger{{Event: "push"}}, triggers)
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		workflow, err := ReadWorkflow(strings.NewReader("on: [push, pull_request]\njobs: {}\n"))
+		assert.NoError(t, err)
+		triggers, err := workflow.Triggers()
+		assert.NoError(t, err)
+		assert.Equal(t, []Trigger{{Event: "push"}, {Event: "pull_request"}}, triggers)
+	})
+
+	t.Run("mapping", func(t *testing.T) {
+		workflow, err := ReadWorkflow(strings.NewReader(`
+on:
+  push:
+    branches: [main, 'releases/**']
+    tags: v*
+    paths-ignore:
+    - docs/**
+  pull_request:
+    types: opened
+  schedule:
+  - cron: '0 0 * * *'
+  - cron: '30 12 * * 1'
+  workflow_dispatch:
+    inputs:
+      level:
+        type: choice
+        options: [info, debug]
+        default: info
+  workflow_run:
+    workflows: [build]
+    types: [completed]
+  workflow_call:
+    inputs:
+      version:
+        type: string
+        required: true
+    outputs:
+      digest:
+        value: ${{ jobs.build.outputs.digest }}
+    secrets:
+      token:
+        required: true
+  release:
+jobs: {}
+`))
+		assert.NoError(t, err)
+		triggers, err := workflow.Triggers()
+		assert.NoError(t, err)
+		assert.Equal(t, []Trigger{
+			{Event: "push", WorkflowRunTrigger: WorkflowRunTrigger{Branches: []string{"main", "releases/**"}}, Tags: []string{"v*"}, PathsIgnore: []string{"docs/**"}},
+			{Event: "pull_request", WorkflowRunTrigger: WorkflowRunTrigger{Types: []string{"opened"}}},
+			{Event: "schedule", Cron: []string{"0 0 * * *", "30 12 * * 1"}},
+			{Event: "workflow_dispatch", WorkflowDispatch: &WorkflowDispatch{Inputs: map[string]WorkflowDispatchInput{
+				"level": {Type: "choice", Options: []string{"info", "debug"}, Default: "info"},
+			}}},
+			{Event: "workflow_run", WorkflowRunTrigger: WorkflowRunTrigger{Workflows: []string{"build"}, Types: []string{"completed"}}},
+			{Event: "workflow_call", WorkflowCall: &WorkflowCall{
+				Inputs:  map[string]WorkflowCallInput{"version": {Type: "string", Required: true}},
+				Outputs: map[string]WorkflowCallOutput{"digest": {Value: "${{ jobs.build.outputs.digest }}"}},
+				Secrets: map[string]WorkflowCallSecret{"token": {Required: true}},
+			}},
+			{Event: "release"},
+		}, triggers)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		workflow, err := ReadWorkflow(strings.NewReader("on:\n  push:\n    branches: {main: true}\njobs: {}\n"))
+		assert.NoError(t, err)
+		_, err = workflow.Triggers()
+		assert.ErrorContains(t, err, "invalid 'on.push'")
+	})
+}
@@ -2,6 +2,7 @@ package runner
 
 import (
 	"context"
+	"crypto/sha256"
 	"embed"
 	"errors"
 	"fmt"
@@ -12,6 +13,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/kballard/go-shellquote"
@@ -43,8 +46,10 @@ var trampoline embed.FS
 func readActionImpl(ctx context.Context, step *model.Step, actionDir string, actionPath string, readFile actionYamlReader, writeFile fileWriter) (*model.Action, error) {
 	logger := common.Logger(ctx)
 	allErrors := []error{}
+	notFound := true
 	addError := func(fileName string, err error) {
 		if err != nil {
+			notFound = notFound && errors.Is(err, fs.ErrNotExist)
 			allErrors = append(allErrors, fmt.Errorf("failed to read '%s' from action '%s' with path '%s' of step %w", fileName, step.String(), actionPath, err))
 		} else {
 			// One successful read, clear error state
@@ -107,15 +112,49 @@ func readActionImpl(ctx context.Context, step *model.Step, actionDir string, act
 		}
 	}
 	if allErrors != nil {
+		if notFound {
+			return nil, fmt.Errorf("no action metadata found for action '%s' with path '%s', tried action.yml, action.yaml and Dockerfile: %w", step.String(), actionPath, fs.ErrNotExist)
+		}
 		return nil, errors.Join(allErrors...)
 	}
 	defer closer.Close()
 
 	action, err := model.ReadAction(reader)
 	logger.Debugf("Read action %v from '%s'", action, "Unknown")
+	if err == nil {
+		warnDeprecatedInputs(ctx, step, action)
+	}
 	return action, err
 }
 
+// warnDeprecatedInputs warns about inputs passed via `with` which the action marked as deprecated
+func warnDeprecatedInputs(ctx context.Context, step *model.Step, action *model.Action) {
+	logger := common.Logger(ctx)
+	for k := range step.With {
+		for inputID, input := range action.Inputs {
+			if strings.EqualFold(k, inputID) && input.DeprecationMessage != "" {
+				logger.Warnf("Input '%s' has been deprecated with message: %s", inputID, input.DeprecationMessage)
+			}
+		}
+	}
+}
+
+// defaultDeprecatedActionRuntimes are the runtimes warned about unless Config.DeprecatedActionRuntimes is set
+var defaultDeprecatedActionRuntimes = []model.ActionRunsUsing{model.ActionRunsUsingNode12, model.ActionRunsUsingNode16}
+
+// warnDeprecatedRuntime warns if the action runs with one of the deprecated runtimes
+func warnDeprecatedRuntime(ctx context.Context, step *model.Step, action *model.Action, deprecated []model.ActionRunsUsing) {
+	if deprecated == nil {
+		deprecated = defaultDeprecatedActionRuntimes
+	}
+	for _, using := range deprecated {
+		if strings.EqualFold(string(action.Runs.Using), string(using)) {
+			common.Logger(ctx).Warnf("Action '%s' uses the deprecated runtime %s, it should be updated to a newer version", step.Uses, action.Runs.Using)
+			return
+		}
+	}
+}
+
 func maybeCopyToActionDir(ctx context.Context, step actionStep, actionDir string, actionPath string, containerActionDir string) error {
 	logger := common.Logger(ctx)
 	rc := step.getRunContext()
@@ -163,6 +202,7 @@ func runActionImpl(step actionStep, actionDir string, remoteAction *remoteAction
 
 		action := step.getActionModel()
 		logger.Debugf("About to run action %v", action)
+		warnDeprecatedRuntime(ctx, stepModel, action, rc.Config.DeprecatedActionRuntimes)
 
 		err := setupActionEnv(ctx, step, remoteAction)
 		if err != nil {
@@ -218,9 +258,7 @@ func setupActionEnv(ctx context.Context, step actionStep, _ *remoteAction) error
 	// the action
 	rc.withGithubEnv(ctx, step.getGithubContext(ctx), *step.getEnv())
 	populateEnvsFromSavedState(step.getEnv(), step, rc)
-	populateEnvsFromInput(ctx, step.getEnv(), step.getActionModel(), rc)
-
-	return nil
+	return populateEnvsFromInput(ctx, step.getEnv(), step.getActionModel(), rc)
 }
 
 // https://github.com/nektos/act/issues/228#issuecomment-629709055
@@ -240,6 +278,33 @@ func removeGitIgnore(ctx context.Context, directory string) error {
 	return nil
 }
 
+// dockerActionImage returns the tag of the image built for a docker action. Images of remote
+// actions are tagged with the resolved sha of the action, so that they are only rebuilt once
+// the action changes. A hash of the build args is part of the tag, so that different build
+// args don't reuse an image built with other ones.
+func dockerActionImage(actionName string, sha string, buildArgs map[string]string) string {
+	tag := "latest"
+	if sha != "" {
+		tag = sha
+	}
+	if len(buildArgs) > 0 {
+		keys := make([]string, 0, len(buildArgs))
+		for k := range buildArgs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		hash := sha256.New()
+		for _, k := range keys {
+			fmt.Fprintf(hash, "%s=%s\x00", k, buildArgs[k])
+		}
+		tag = fmt.Sprintf("%s-%x", tag, hash.Sum(nil)[:6])
+	}
+	// "-dockeraction" enshures that "./", "./test " won't get converted to "act-:latest", "act-test-:latest" which are invalid docker image names
+	image := fmt.Sprintf("%s-dockeraction:%s", regexp.MustCompile("[^a-zA-Z0-9]").ReplaceAllString(actionName, "-"), tag)
+	image = fmt.Sprintf("act-%s", strings.TrimLeft(image, "-"))
+	return strings.ToLower(image)
+}
+
 // TODO: break out parts of function to reduce complexicity
 //
 //nolint:gocyclo
@@ -252,14 +317,16 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 	var image string
 	forcePull := false
 	if strings.HasPrefix(action.Runs.Image, "docker://") {
-		image = strings.TrimPrefix(action.Runs.Image, "docker://")
+		image = container.MirrorImage(strings.TrimPrefix(action.Runs.Image, "docker://"), rc.Config.RegistryMirrors)
 		// Apply forcePull only for prebuild docker images
 		forcePull = rc.Config.ForcePull
 	} else {
-		// "-dockeraction" enshures that "./", "./test " won't get converted to "act-:latest", "act-test-:latest" which are invalid docker image names
-		image = fmt.Sprintf("%s-dockeraction:%s", regexp.MustCompile("[^a-zA-Z0-9]").ReplaceAllString(actionName, "-"), "latest")
-		image = fmt.Sprintf("act-%s", strings.TrimLeft(image, "-"))
-		image = strings.ToLower(image)
+		var sha string
+		if rstep, ok := step.(*stepActionRemote); ok {
+			sha = rstep.resolvedSha
+		}
+		buildArgs := actionBuildArgs(ctx, step)
+		image = dockerActionImage(actionName, sha, buildArgs)
 		contextDir, fileName := filepath.Split(filepath.Join(basedir, action.Runs.Image))
 
 		anyArchExists, err := container.ImageExistsLocally(ctx, image, "any")
@@ -305,6 +372,7 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 				ImageTag:     image,
 				BuildContext: buildContext,
 				Platform:     rc.Config.ContainerArchitecture,
+				BuildArgs:    buildArgs,
 			})
 		} else {
 			logger.Debugf("image '%s' for architecture '%s' already exists", image, rc.Config.ContainerArchitecture)
@@ -330,7 +398,7 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 			entrypoint = nil
 		}
 	}
-	stepContainer := newStepContainer(ctx, step, image, cmd, entrypoint)
+	stepContainer := newStepContainer(ctx, step, image, cmd, entrypoint, len(entrypoint) == 0 && action.Runs.ClearEntrypoint)
 	return common.NewPipelineExecutor(
 		prepImage,
 		stepContainer.Pull(forcePull),
@@ -371,18 +439,10 @@ func evalDockerArgs(ctx context.Context, step step, action *model.Action, cmd *[
 	}
 }
 
-func newStepContainer(ctx context.Context, step step, image string, cmd []string, entrypoint []string) container.Container {
+func newStepContainer(ctx context.Context, step step, image string, cmd []string, entrypoint []string, clearEntrypoint bool) container.Container {
 	rc := step.getRunContext()
 	stepModel := step.getStepModel()
-	rawLogger := common.Logger(ctx).WithField("raw_output", true)
-	logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-		if rc.Config.LogOutput {
-			rawLogger.Infof("%s", s)
-		} else {
-			rawLogger.Debugf("%s", s)
-		}
-		return true
-	})
+	logWriter := rc.newLogWriter(ctx)
 	envList := make([]string, 0)
 	for k, v := range *step.getEnv() {
 		envList = append(envList, fmt.Sprintf("%s=%s", k, v))
@@ -394,28 +454,30 @@ func newStepContainer(ctx context.Context, step step, image string, cmd []string
 	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TEMP", "/tmp"))
 
 	binds, mounts := rc.GetBindsAndMounts()
+	username, password := rc.registryCredentials(ctx, image)
 	networkMode := fmt.Sprintf("container:%s", rc.jobContainerName())
 	if rc.IsHostEnv(ctx) {
 		networkMode = "default"
 	}
-	stepContainer := container.NewContainer(&container.NewContainerInput{
-		Cmd:         cmd,
-		Entrypoint:  entrypoint,
-		WorkingDir:  rc.JobContainer.ToContainerPath(rc.Config.Workdir),
-		Image:       image,
-		Username:    rc.Config.Secrets["DOCKER_USERNAME"],
-		Password:    rc.Config.Secrets["DOCKER_PASSWORD"],
-		Name:        createContainerName(rc.jobContainerName(), stepModel.ID),
-		Env:         envList,
-		Mounts:      mounts,
-		NetworkMode: networkMode,
-		Binds:       binds,
-		Stdout:      logWriter,
-		Stderr:      logWriter,
-		Privileged:  rc.Config.Privileged,
-		UsernsMode:  rc.Config.UsernsMode,
-		Platform:    rc.Config.ContainerArchitecture,
-		Options:     rc.Config.ContainerOptions,
+	stepContainer := ContainerNewContainer(&container.NewContainerInput{
+		Cmd:             cmd,
+		Entrypoint:      entrypoint,
+		ClearEntrypoint: clearEntrypoint,
+		WorkingDir:      rc.JobContainer.ToContainerPath(rc.Config.Workdir),
+		Image:           image,
+		Username:        username,
+		Password:        password,
+		Name:            createContainerName(rc.jobContainerName(), stepModel.ID),
+		Env:             envList,
+		Mounts:          mounts,
+		NetworkMode:     networkMode,
+		Binds:           binds,
+		Stdout:          logWriter,
+		Stderr:          logWriter,
+		Privileged:      rc.Config.Privileged,
+		UsernsMode:      rc.Config.UsernsMode,
+		Platform:        rc.Config.ContainerArchitecture,
+		Options:         rc.Config.ContainerOptions,
 	})
 	return stepContainer
 }
@@ -430,21 +492,79 @@ func populateEnvsFromSavedState(env *map[string]string, step actionStep, rc *Run
 	}
 }
 
-func populateEnvsFromInput(ctx context.Context, env *map[string]string, action *model.Action, rc *RunContext) {
+func populateEnvsFromInput(ctx context.Context, env *map[string]string, action *model.Action, rc *RunContext) error {
 	eval := rc.NewExpressionEvaluator(ctx)
-	for inputID, input := range action.Inputs {
+	inputIDs := make([]string, 0, len(action.Inputs))
+	for inputID := range action.Inputs {
+		inputIDs = append(inputIDs, inputID)
+	}
+	sort.Strings(inputIDs)
+
+	for _, inputID := range inputIDs {
+		input := action.Inputs[inputID]
 		envKey := regexp.MustCompile("[^A-Z0-9-]").ReplaceAllString(strings.ToUpper(inputID), "_")
 		envKey = fmt.Sprintf("INPUT_%s", envKey)
-		if _, ok := (*env)[envKey]; !ok {
-			(*env)[envKey] = eval.Interpolate(ctx, input.Default)
+		value, ok := (*env)[envKey]
+		if !ok {
+			if input.Required && input.Default == "" {
+				return fmt.Errorf("input required and not supplied: %s", inputID)
+			}
+			value = eval.Interpolate(ctx, input.Default)
+		}
+		value, err := coerceInput(inputID, input.Type, value)
+		if err != nil {
+			return err
+		}
+		(*env)[envKey] = value
+	}
+	return nil
+}
+
+// coerceInput normalizes the value of an input according to the type declared by the action
+func coerceInput(inputID string, inputType string, value string) (string, error) {
+	switch strings.ToLower(inputType) {
+	case "boolean":
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("input '%s' must be a boolean, got '%s'", inputID, value)
+		}
+		return strconv.FormatBool(b), nil
+	case "number":
+		if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+			return "", fmt.Errorf("input '%s' must be a number, got '%s'", inputID, value)
+		}
+		return strings.TrimSpace(value), nil
+	default:
+		return value, nil
+	}
+}
+
+// actionBuildArgs returns the build args of a Dockerfile action, the
+// interpolated runs.build-args of the action override Config.ContainerBuildArgs.
+// runs.build-args is an act extension, GitHub ignores it.
+func actionBuildArgs(ctx context.Context, step actionStep) map[string]string {
+	rc := step.getRunContext()
+	buildArgs := make(map[string]string, len(rc.Config.ContainerBuildArgs))
+	for k, v := range rc.Config.ContainerBuildArgs {
+		buildArgs[k] = v
+	}
+	if action := step.getActionModel(); len(action.Runs.BuildArgs) > 0 {
+		eval := rc.NewStepExpressionEvaluator(ctx, step)
+		for k, v := range action.Runs.BuildArgs {
+			buildArgs[k] = eval.Interpolate(ctx, v)
 		}
 	}
+	return buildArgs
 }
 
 func getContainerActionPaths(step *model.Step, actionDir string, rc *RunContext) (string, string) {
 	actionName := ""
 	containerActionDir := "."
-	if step.Type() != model.StepTypeUsesActionRemote {
+	if step.Type() != model.StepTypeUsesActionRemote && !rc.isInWorkdir(actionDir) {
+		actionName = getOsSafeRelativePath(actionDir, rc.Config.WorkflowsDir)
+		containerActionDir = rc.JobContainer.GetActPath() + "/workflows-dir/" + actionName
+		actionName = "./" + actionName
+	} else if step.Type() != model.StepTypeUsesActionRemote {
 		actionName = getOsSafeRelativePath(actionDir, rc.Config.Workdir)
 		containerActionDir = rc.JobContainer.ToContainerPath(rc.Config.Workdir) + "/" + actionName
 		actionName = "./" + actionName
@@ -508,7 +628,9 @@ func runPreStep(step actionStep) common.Executor {
 		switch action.Runs.Using {
 		case model.ActionRunsUsingNode12, model.ActionRunsUsingNode16, model.ActionRunsUsingNode20:
 			// defaults in pre steps were missing, however provided inputs are available
-			populateEnvsFromInput(ctx, step.getEnv(), action, rc)
+			if err := populateEnvsFromInput(ctx, step.getEnv(), action, rc); err != nil {
+				return err
+			}
 			// todo: refactor into step
 			var actionDir string
 			var actionPath string
@@ -516,7 +638,7 @@ func runPreStep(step actionStep) common.Executor {
 				actionPath = newRemoteAction(stepModel.Uses).Path
 				actionDir = fmt.Sprintf("%s/%s", rc.ActionCacheDir(), safeFilename(stepModel.Uses))
 			} else {
-				actionDir = filepath.Join(rc.Config.Workdir, stepModel.Uses)
+				actionDir = rc.localActionDir(stepModel.Uses)
 				actionPath = ""
 			}
 
@@ -608,7 +730,7 @@ func runPostStep(step actionStep) common.Executor {
 			actionPath = newRemoteAction(stepModel.Uses).Path
 			actionDir = fmt.Sprintf("%s/%s", rc.ActionCacheDir(), safeFilename(stepModel.Uses))
 		} else {
-			actionDir = filepath.Join(rc.Config.Workdir, stepModel.Uses)
+			actionDir = rc.localActionDir(stepModel.Uses)
 			actionPath = ""
 		}
 
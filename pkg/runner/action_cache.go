@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"path"
@@ -117,36 +118,73 @@ func (c GoGitActionCache) GetTarArchive(ctx context.Context, cacheDir, sha, incl
 			} else if cleanIncludePrefix != "." && name != cleanIncludePrefix {
 				return nil
 			}
-			fmode, err := f.Mode.ToOSFileMode()
-			if err != nil {
-				return err
-			}
-			if fmode&fs.ModeSymlink == fs.ModeSymlink {
-				content, err := f.Contents()
-				if err != nil {
-					return err
-				}
-				return tw.WriteHeader(&tar.Header{
-					Name:     name,
-					Mode:     int64(fmode),
-					Linkname: content,
-				})
-			}
-			err = tw.WriteHeader(&tar.Header{
-				Name: name,
-				Mode: int64(fmode),
-				Size: f.Size,
-			})
-			if err != nil {
-				return err
-			}
-			reader, err := f.Reader()
-			if err != nil {
-				return err
-			}
-			_, err = io.Copy(tw, reader)
-			return err
+			return writeTarEntry(tw, name, f)
 		}))
 	}()
 	return rpipe, err
 }
+
+// maxSymlinkTargetLength bounds the target read for a symlink, it's the
+// PATH_MAX of linux
+const maxSymlinkTargetLength = 4096
+
+// writeTarEntry streams the blob of f into tw without buffering it in memory.
+// The header size is the number of bytes the blob reader yields, which is
+// counted in a first pass, as the size stored with the object isn't
+// necessarily the size of its content.
+func writeTarEntry(tw *tar.Writer, name string, f *object.File) error {
+	fmode, err := f.Mode.ToOSFileMode()
+	if err != nil {
+		return err
+	}
+	if fmode&fs.ModeSymlink == fs.ModeSymlink {
+		reader, err := f.Reader()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		linkname, err := io.ReadAll(io.LimitReader(reader, maxSymlinkTargetLength+1))
+		if err != nil {
+			return fmt.Errorf("failed to read symlink target of '%s': %w", f.Name, err)
+		}
+		if len(linkname) > maxSymlinkTargetLength {
+			return fmt.Errorf("symlink target of '%s' is longer than %d bytes", f.Name, maxSymlinkTargetLength)
+		}
+		return tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     int64(fmode),
+			Linkname: string(linkname),
+		})
+	}
+	size, err := blobSize(f)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", f.Name, err)
+	}
+	reader, err := f.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	err = tw.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: int64(fmode),
+		Size: size,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(tw, reader, size); err != nil {
+		return fmt.Errorf("failed to read '%s': %w", f.Name, err)
+	}
+	return nil
+}
+
+// blobSize counts the bytes the blob reader of f yields
+func blobSize(f *object.File) (int64, error) {
+	reader, err := f.Reader()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	return io.Copy(io.Discard, reader)
+}
@@ -6,8 +6,15 @@ import (
 	"context"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -75,3 +82,101 @@ func TestActionCache(t *testing.T) {
 		})
 	}
 }
+
+func TestActionCacheLargeFile(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	repoDir := t.TempDir()
+	repo, err := git.PlainInit(repoDir, false)
+	if !a.NoError(err) {
+		return
+	}
+	content := bytes.Repeat([]byte("0123456789abcdef"), 4<<20/16)
+	a.NoError(os.WriteFile(filepath.Join(repoDir, "large.bin"), content, 0o600))
+	a.NoError(os.Symlink("large.bin", filepath.Join(repoDir, "link")))
+	wt, err := repo.Worktree()
+	if !a.NoError(err) {
+		return
+	}
+	_, err = wt.Add(".")
+	a.NoError(err)
+	_, err = wt.Commit("large file", &git.CommitOptions{
+		Author: &object.Signature{Name: "act", Email: "act@example.com", When: time.Now()},
+	})
+	if !a.NoError(err) {
+		return
+	}
+
+	cache := &GoGitActionCache{
+		Path: t.TempDir(),
+	}
+	sha, err := cache.Fetch(ctx, "large/file", repoDir, "HEAD", "")
+	if !a.NoError(err) {
+		return
+	}
+	atar, err := cache.GetTarArchive(ctx, "large/file", sha, "")
+	if !a.NoError(err) {
+		return
+	}
+	defer atar.Close()
+
+	mytar := tar.NewReader(atar)
+	entries := map[string]*tar.Header{}
+	for {
+		th, err := mytar.Next()
+		if err == io.EOF {
+			break
+		}
+		if !a.NoError(err) {
+			return
+		}
+		entries[th.Name] = th
+		if th.Name == "large.bin" {
+			n, err := io.Copy(io.Discard, mytar)
+			a.NoError(err)
+			a.Equal(th.Size, n)
+			a.Equal(int64(len(content)), n)
+		}
+	}
+	if a.Contains(entries, "link") {
+		a.Equal("large.bin", entries["link"].Linkname)
+	}
+	a.Contains(entries, "large.bin")
+}
+
+func TestWriteTarEntrySize(t *testing.T) {
+	newFile := func(name string, mode filemode.FileMode, content string, size int64) *object.File {
+		obj := &plumbing.MemoryObject{}
+		obj.SetType(plumbing.BlobObject)
+		_, err := obj.Write([]byte(content))
+		assert.NoError(t, err)
+		blob, err := object.DecodeBlob(obj)
+		assert.NoError(t, err)
+		// the size stored with the object doesn't match its content
+		blob.Size = size
+		return object.NewFile(name, mode, blob)
+	}
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	assert.NoError(t, writeTarEntry(tw, "file", newFile("file", filemode.Regular, "content", 64)))
+	assert.NoError(t, writeTarEntry(tw, "link", newFile("link", filemode.Symlink, "../target", 2)))
+	assert.NoError(t, tw.Close())
+
+	tr := tar.NewReader(buf)
+	th, err := tr.Next()
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(len("content")), th.Size)
+		content, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		assert.Equal(t, "content", string(content))
+	}
+	th, err = tr.Next()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "../target", th.Linkname)
+	}
+
+	long := newFile("long", filemode.Symlink, strings.Repeat("a", maxSymlinkTargetLength+1), 1)
+	assert.ErrorContains(t, writeTarEntry(tar.NewWriter(io.Discard), "long", long), "longer than 4096 bytes")
+}
@@ -50,15 +50,18 @@ func newCompositeRunContext(ctx context.Context, parent *RunContext, step action
 	// run with the global config but without secrets
 	configCopy := *(parent.Config)
 	configCopy.Secrets = nil
+	configCopy.SecretResolver = nil
 
-	// create a run context for the composite action to run in
+	// create a run context for the composite action to run in,
+	// its steps get the defaults of the action instead of the caller's
 	compositerc := &RunContext{
 		Name:    parent.Name,
 		JobName: parent.JobName,
 		Run: &model.Run{
 			JobID: parent.Run.JobID,
 			Workflow: &model.Workflow{
-				Name: parent.Run.Workflow.Name,
+				Name:     parent.Run.Workflow.Name,
+				Defaults: step.getActionModel().Defaults,
 				Jobs: map[string]*model.Job{
 					parent.Run.JobID: {},
 				},
@@ -74,6 +77,8 @@ func newCompositeRunContext(ctx context.Context, parent *RunContext, step action
 		ExtraPath:    parent.ExtraPath,
 		Parent:       parent,
 		EventJSON:    parent.EventJSON,
+		runID:        parent.runID,
+		jobCancels:   parent.jobCancels,
 	}
 	compositerc.ExprEval = compositerc.NewExpressionEvaluator(ctx)
 
@@ -136,14 +141,20 @@ func (rc *RunContext) compositeExecutor(action *model.Action) *compositeSteps {
 
 	sf := &stepFactoryImpl{}
 
-	for i, step := range action.Runs.Steps {
-		if step.ID == "" {
-			step.ID = fmt.Sprintf("%d", i)
+	stepIDs := map[string]bool{}
+	for _, step := range action.Runs.Steps {
+		if step.ID != "" {
+			stepIDs[step.ID] = true
 		}
+	}
 
+	for i, step := range action.Runs.Steps {
 		// create a copy of the step, since this composite action could
 		// run multiple times and we might modify the instance
 		stepcopy := step
+		if stepcopy.ID == "" {
+			stepcopy.ID = defaultStepID(stepIDs, i)
+		}
 
 		step, err := sf.newStep(&stepcopy, rc)
 		if err != nil {
@@ -201,15 +212,7 @@ func (rc *RunContext) newCompositeCommandExecutor(executor common.Executor) comm
 		// handler into the current running job container
 		// We need this, to support scoping commands to the composite action
 		// executing.
-		rawLogger := common.Logger(ctx).WithField("raw_output", true)
-		logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-			if rc.Config.LogOutput {
-				rawLogger.Infof("%s", s)
-			} else {
-				rawLogger.Debugf("%s", s)
-			}
-			return true
-		})
+		logWriter := rc.newLogWriter(ctx)
 
 		oldout, olderr := rc.JobContainer.ReplaceLogWriter(logWriter, logWriter)
 		defer rc.JobContainer.ReplaceLogWriter(oldout, olderr)
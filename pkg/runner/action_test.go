@@ -7,7 +7,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
 	"github.com/nektos/act/pkg/model"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -140,6 +144,30 @@ runs:
 	}
 }
 
+func TestActionReaderNotFound(t *testing.T) {
+	readFile := func(filename string) (io.Reader, io.Closer, error) {
+		return nil, nil, fs.ErrNotExist
+	}
+	writeFile := func(filename string, data []byte, perm fs.FileMode) error {
+		return nil
+	}
+
+	action, err := readActionImpl(context.Background(), &model.Step{Uses: "org/repo@v1"}, "actionDir", "actionPath", readFile, writeFile)
+	assert.Nil(t, action)
+	assert.EqualError(t, err, "no action metadata found for action 'org/repo@v1' with path 'actionPath', tried action.yml, action.yaml and Dockerfile: file does not exist")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	// other errors are reported as they are
+	readFile = func(filename string) (io.Reader, io.Closer, error) {
+		if filename == "action.yaml" {
+			return nil, nil, fs.ErrPermission
+		}
+		return nil, nil, fs.ErrNotExist
+	}
+	_, err = readActionImpl(context.Background(), &model.Step{Uses: "org/repo@v1"}, "actionDir", "actionPath", readFile, writeFile)
+	assert.ErrorIs(t, err, fs.ErrPermission)
+}
+
 func TestActionRunner(t *testing.T) {
 	table := []struct {
 		name        string
@@ -247,3 +275,235 @@ func TestActionRunner(t *testing.T) {
 		})
 	}
 }
+
+func TestActionReaderDeprecatedInput(t *testing.T) {
+	yaml := strings.ReplaceAll(`
+name: 'name'
+inputs:
+	token:
+		description: 'token'
+	old-input:
+		description: 'old input'
+		deprecationMessage: 'use token instead'
+runs:
+	using: 'node16'
+	main: 'main.js'
+`, "\t", "  ")
+
+	step := &model.Step{
+		With: map[string]string{
+			"token":     "value",
+			"old-input": "value",
+		},
+	}
+
+	readFile := func(filename string) (io.Reader, io.Closer, error) {
+		if filename != "action.yml" {
+			return nil, nil, fs.ErrNotExist
+		}
+		return strings.NewReader(yaml), io.NopCloser(nil), nil
+	}
+
+	logger, hook := test.NewNullLogger()
+	ctx := common.WithLogger(context.Background(), logger)
+
+	action, err := readActionImpl(ctx, step, "actionDir", "actionPath", readFile, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "use token instead", action.Inputs["old-input"].DeprecationMessage)
+
+	warnings := []string{}
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel {
+			warnings = append(warnings, entry.Message)
+		}
+	}
+	assert.Equal(t, []string{"Input 'old-input' has been deprecated with message: use token instead"}, warnings)
+}
+
+func TestWarnDeprecatedRuntime(t *testing.T) {
+	step := &model.Step{Uses: "actions/setup-node@v3"}
+	warnings := func(using model.ActionRunsUsing, deprecated []model.ActionRunsUsing) []string {
+		logger, hook := test.NewNullLogger()
+		ctx := common.WithLogger(context.Background(), logger)
+		warnDeprecatedRuntime(ctx, step, &model.Action{Runs: model.ActionRuns{Using: using}}, deprecated)
+		messages := []string{}
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.WarnLevel {
+				messages = append(messages, entry.Message)
+			}
+		}
+		return messages
+	}
+
+	assert.Equal(t, []string{"Action 'actions/setup-node@v3' uses the deprecated runtime node16, it should be updated to a newer version"}, warnings(model.ActionRunsUsingNode16, nil))
+	assert.Empty(t, warnings(model.ActionRunsUsingNode20, nil))
+	assert.Empty(t, warnings(model.ActionRunsUsingNode16, []model.ActionRunsUsing{}))
+	assert.Len(t, warnings(model.ActionRunsUsingNode20, []model.ActionRunsUsing{model.ActionRunsUsingNode20}), 1)
+}
+
+func TestDockerActionImage(t *testing.T) {
+	assert.Equal(t, "act-dockeraction:latest", dockerActionImage("./", "", nil))
+	assert.Equal(t, "act-test-dockeraction:latest", dockerActionImage("./test", "", nil))
+	assert.Equal(t, "act-owner-repo-v1-dockeraction:0123abcd", dockerActionImage("owner/Repo@v1", "0123ABCD", nil))
+
+	// build args are hashed into the tag, independent of their order
+	withArgs := dockerActionImage("./test", "", map[string]string{"A": "1", "B": "2"})
+	assert.Regexp(t, `^act-test-dockeraction:latest-[0-9a-f]{12}$`, withArgs)
+	assert.Equal(t, withArgs, dockerActionImage("./test", "", map[string]string{"B": "2", "A": "1"}))
+	assert.NotEqual(t, withArgs, dockerActionImage("./test", "", map[string]string{"A": "1", "B": "3"}))
+}
+
+func TestPopulateEnvsFromInput(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{},
+		Run: &model.Run{
+			JobID: "job1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"job1": {},
+				},
+			},
+		},
+	}
+	action := &model.Action{
+		Inputs: map[string]model.Input{
+			"token":   {Required: true},
+			"debug":   {Type: "boolean", Default: "false"},
+			"retries": {Type: "number", Default: "3"},
+			"name":    {Default: "${{ 'act' }}"},
+		},
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		env := map[string]string{"INPUT_TOKEN": "abc"}
+		assert.NoError(t, populateEnvsFromInput(context.Background(), &env, action, rc))
+		assert.Equal(t, map[string]string{
+			"INPUT_TOKEN":   "abc",
+			"INPUT_DEBUG":   "false",
+			"INPUT_RETRIES": "3",
+			"INPUT_NAME":    "act",
+		}, env)
+	})
+
+	t.Run("coerced", func(t *testing.T) {
+		env := map[string]string{"INPUT_TOKEN": "abc", "INPUT_DEBUG": "True", "INPUT_RETRIES": " 5 "}
+		assert.NoError(t, populateEnvsFromInput(context.Background(), &env, action, rc))
+		assert.Equal(t, "true", env["INPUT_DEBUG"])
+		assert.Equal(t, "5", env["INPUT_RETRIES"])
+	})
+
+	t.Run("required missing", func(t *testing.T) {
+		env := map[string]string{}
+		assert.EqualError(t, populateEnvsFromInput(context.Background(), &env, action, rc), "input required and not supplied: token")
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		env := map[string]string{"INPUT_TOKEN": "abc", "INPUT_DEBUG": "yes please"}
+		assert.EqualError(t, populateEnvsFromInput(context.Background(), &env, action, rc), "input 'debug' must be a boolean, got 'yes please'")
+	})
+}
+
+func TestActionBuildArgs(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			ContainerBuildArgs: map[string]string{
+				"HTTP_PROXY": "http://proxy:3128",
+				"VERSION":    "latest",
+			},
+		},
+		Run: &model.Run{
+			JobID: "job1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"job1": {},
+				},
+			},
+		},
+		StepResults: map[string]*model.StepResult{},
+	}
+	rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+
+	sal := &stepActionLocal{
+		Step:       &model.Step{ID: "docker", Uses: "./action"},
+		RunContext: rc,
+		env:        map[string]string{"INPUT_VERSION": "1.2.3"},
+		action: &model.Action{
+			Runs: model.ActionRuns{
+				Using: "docker",
+				Image: "Dockerfile",
+				BuildArgs: map[string]string{
+					"VERSION": "${{ inputs.version }}",
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"HTTP_PROXY": "http://proxy:3128",
+		"VERSION":    "1.2.3",
+	}, actionBuildArgs(context.Background(), sal))
+}
+
+func TestExecAsDockerClearEntrypoint(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		runs            string
+		with            map[string]string
+		entrypoint      []string
+		clearEntrypoint bool
+	}{
+		{"image entrypoint", "", nil, nil, false},
+		{"empty entrypoint", `entrypoint: ""`, nil, nil, true},
+		{"action entrypoint", "entrypoint: /entrypoint.sh", nil, []string{"/entrypoint.sh"}, false},
+		{"step entrypoint", `entrypoint: ""`, map[string]string{"entrypoint": "/bin/sh"}, []string{"/bin/sh"}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := model.ReadAction(strings.NewReader("runs:\n  using: docker\n  image: docker://alpine:3\n  " + tt.runs + "\n"))
+			assert.NoError(t, err)
+
+			cm := &containerMock{}
+			var input *container.NewContainerInput
+			origContainerNewContainer := ContainerNewContainer
+			ContainerNewContainer = func(containerInput *container.NewContainerInput) container.ExecutionsEnvironment {
+				input = containerInput
+				return cm
+			}
+			defer (func() {
+				ContainerNewContainer = origContainerNewContainer
+			})()
+			for _, method := range []string{"Remove", "Close"} {
+				cm.On(method).Return(func(ctx context.Context) error { return nil })
+			}
+			cm.On("Pull", false).Return(func(ctx context.Context) error { return nil })
+			cm.On("Create", []string(nil), []string(nil)).Return(func(ctx context.Context) error { return nil })
+			cm.On("Start", true).Return(func(ctx context.Context) error { return nil })
+
+			rc := &RunContext{
+				Config: &Config{},
+				Run: &model.Run{
+					JobID: "job1",
+					Workflow: &model.Workflow{
+						Jobs: map[string]*model.Job{
+							"job1": {},
+						},
+					},
+				},
+				StepResults:  map[string]*model.StepResult{},
+				JobContainer: cm,
+			}
+			rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+			sal := &stepActionLocal{
+				Step:       &model.Step{ID: "docker", Uses: "./action", With: tt.with},
+				RunContext: rc,
+				env:        map[string]string{},
+				action:     action,
+			}
+
+			assert.NoError(t, execAsDocker(context.Background(), sal, "action", "./action", true))
+			if assert.NotNil(t, input) {
+				assert.Equal(t, tt.entrypoint, input.Entrypoint)
+				assert.Equal(t, tt.clearEntrypoint, input.ClearEntrypoint)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Annotation is a message created by the ::error::, ::warning:: or ::notice:: workflow commands
+type Annotation struct {
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Title     string `json:"title,omitempty"`
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	StepID    string `json:"stepId,omitempty"`
+}
+
+func (rc *RunContext) addAnnotation(level string, kvPairs map[string]string, arg string) {
+	atoi := func(key string) int {
+		i, _ := strconv.Atoi(kvPairs[key])
+		return i
+	}
+	annotation := Annotation{
+		Level:     level,
+		Message:   arg,
+		Title:     kvPairs["title"],
+		File:      kvPairs["file"],
+		Line:      atoi("line"),
+		EndLine:   atoi("endLine"),
+		Column:    atoi("col"),
+		EndColumn: atoi("endColumn"),
+		StepID:    rc.CurrentStep,
+	}
+
+	// annotations of composite actions belong to the job
+	root := rc
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	root.Annotations = append(root.Annotations, annotation)
+}
+
+// AnnotationsJSON returns all annotations collected while running the job as JSON
+func (rc *RunContext) AnnotationsJSON() ([]byte, error) {
+	annotations := rc.Annotations
+	if annotations == nil {
+		annotations = []Annotation{}
+	}
+	return json.Marshal(annotations)
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name string `json:"name"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifResult struct {
+	Level   string `json:"level"`
+	Message struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// AnnotationsSARIF returns all annotations collected while running the job as a SARIF 2.1.0 log
+func (rc *RunContext) AnnotationsSARIF() ([]byte, error) {
+	run := sarifRun{
+		Results: []sarifResult{},
+	}
+	run.Tool.Driver.Name = "act"
+
+	for _, a := range rc.Annotations {
+		result := sarifResult{
+			Level: a.Level,
+		}
+		if a.Level == "notice" {
+			result.Level = "note"
+		}
+		result.Message.Text = a.Message
+
+		if a.File != "" {
+			var location sarifLocation
+			location.PhysicalLocation.ArtifactLocation.URI = a.File
+			if a.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   a.Line,
+					EndLine:     a.EndLine,
+					StartColumn: a.Column,
+					EndColumn:   a.EndColumn,
+				}
+			}
+			result.Locations = []sarifLocation{location}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	return json.Marshal(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
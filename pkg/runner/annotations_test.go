@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotations(t *testing.T) {
+	a := assert.New(t)
+	rc := &RunContext{
+		CurrentStep: "lint",
+	}
+	handler := rc.commandHandler(context.Background())
+
+	handler("::error file=main.go,line=10,col=5,title=Lint::unused variable%0Ax\n")
+	handler("::warning::deprecated API\n")
+
+	out, err := rc.AnnotationsJSON()
+	a.NoError(err)
+	a.JSONEq(`[
+		{"level": "error", "message": "unused variable\nx", "title": "Lint", "file": "main.go", "line": 10, "column": 5, "stepId": "lint"},
+		{"level": "warning", "message": "deprecated API", "stepId": "lint"}
+	]`, string(out))
+
+	out, err = rc.AnnotationsSARIF()
+	a.NoError(err)
+	a.JSONEq(`{
+		"version": "2.1.0",
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"runs": [{
+			"tool": {"driver": {"name": "act"}},
+			"results": [
+				{
+					"level": "error",
+					"message": {"text": "unused variable\nx"},
+					"locations": [{"physicalLocation": {"artifactLocation": {"uri": "main.go"}, "region": {"startLine": 10, "startColumn": 5}}}]
+				},
+				{
+					"level": "warning",
+					"message": {"text": "deprecated API"}
+				}
+			]
+		}]
+	}`, string(out))
+}
+
+func TestAnnotationsEmpty(t *testing.T) {
+	out, err := new(RunContext).AnnotationsJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(out))
+}
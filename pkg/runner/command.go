@@ -2,7 +2,9 @@ package runner
 
 import (
 	"context"
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/nektos/act/pkg/common"
@@ -17,25 +19,78 @@ func init() {
 }
 
 func tryParseRawActionCommand(line string) (command string, kvPairs map[string]string, arg string, ok bool) {
+	command, _, kvPairs, arg, ok = parseRawActionCommand(line)
+	return
+}
+
+// parseRawActionCommand parses a workflow command line, rawKvPairs are its properties as written
+func parseRawActionCommand(line string) (command string, rawKvPairs []string, kvPairs map[string]string, arg string, ok bool) {
 	if m := commandPatternGA.FindStringSubmatch(line); m != nil {
 		command = m[1]
 		kvPairs = parseKeyValuePairs(m[3], ",")
+		if m[3] != "" {
+			rawKvPairs = strings.Split(m[3], ",")
+		}
 		arg = m[4]
 		ok = true
 	} else if m := commandPatternADO.FindStringSubmatch(line); m != nil {
 		command = m[1]
 		kvPairs = parseKeyValuePairs(m[3], ";")
+		if m[3] != "" {
+			rawKvPairs = strings.Split(m[3], ";")
+		}
 		arg = m[4]
 		ok = true
 	}
 	return
 }
 
+var annotationProperties = map[string]bool{
+	"title":     true,
+	"file":      true,
+	"line":      true,
+	"endLine":   true,
+	"col":       true,
+	"endColumn": true,
+}
+
+// validateWorkflowCommand checks a workflow command for Config.StrictWorkflowCommands,
+// it rejects unknown commands, malformed or empty properties and missing names
+func validateWorkflowCommand(command string, rawKvPairs []string, kvPairs map[string]string) error {
+	for _, kvPair := range rawKvPairs {
+		if k, v, ok := strings.Cut(kvPair, "="); !ok || k == "" || v == "" {
+			return fmt.Errorf("malformed property '%s'", kvPair)
+		}
+	}
+
+	switch command {
+	case "set-env", "set-output", "save-state":
+		if kvPairs["name"] == "" {
+			return fmt.Errorf("missing property 'name'")
+		}
+	case "debug", "warning", "error", "notice":
+		for k, v := range kvPairs {
+			if !annotationProperties[k] {
+				return fmt.Errorf("unknown property '%s'", k)
+			}
+			if k != "title" && k != "file" {
+				if _, err := strconv.Atoi(v); err != nil {
+					return fmt.Errorf("property '%s' is not a number", k)
+				}
+			}
+		}
+	case "add-path", "add-mask", "stop-commands", "add-matcher", "remove-matcher", "group", "endgroup", "echo":
+	default:
+		return fmt.Errorf("unknown command")
+	}
+	return nil
+}
+
 func (rc *RunContext) commandHandler(ctx context.Context) common.LineHandler {
 	logger := common.Logger(ctx)
 	resumeCommand := ""
 	return func(line string) bool {
-		command, kvPairs, arg, ok := tryParseRawActionCommand(line)
+		command, rawKvPairs, kvPairs, arg, ok := parseRawActionCommand(line)
 		if !ok {
 			return true
 		}
@@ -44,6 +99,16 @@ func (rc *RunContext) commandHandler(ctx context.Context) common.LineHandler {
 			logger.Infof("  \U00002699  %s", line)
 			return false
 		}
+		if rc.Config != nil && rc.Config.StrictWorkflowCommands && resumeCommand == "" {
+			if err := validateWorkflowCommand(command, rawKvPairs, kvPairs); err != nil {
+				err = fmt.Errorf("invalid workflow command '%s': %w", strings.TrimRight(line, "\r\n"), err)
+				logger.Errorf("  \U00002757  %v", err)
+				if rc.workflowCommandErr == nil {
+					rc.workflowCommandErr = err
+				}
+				return false
+			}
+		}
 		arg = unescapeCommandData(arg)
 		kvPairs = unescapeKvPairs(kvPairs)
 		switch command {
@@ -57,8 +122,13 @@ func (rc *RunContext) commandHandler(ctx context.Context) common.LineHandler {
 			logger.Infof("  \U0001F4AC  %s", line)
 		case "warning":
 			logger.Infof("  \U0001F6A7  %s", line)
+			rc.addAnnotation(command, kvPairs, arg)
 		case "error":
 			logger.Infof("  \U00002757  %s", line)
+			rc.addAnnotation(command, kvPairs, arg)
+		case "notice":
+			logger.Infof("  \U0001F4DD  %s", line)
+			rc.addAnnotation(command, kvPairs, arg)
 		case "add-mask":
 			rc.AddMask(arg)
 			logger.Infof("  \U00002699  %s", "***")
@@ -133,9 +203,9 @@ func parseKeyValuePairs(kvPairs string, separator string) map[string]string {
 	rtn := make(map[string]string)
 	kvPairList := strings.Split(kvPairs, separator)
 	for _, kvPair := range kvPairList {
-		kv := strings.Split(kvPair, "=")
-		if len(kv) == 2 {
-			rtn[kv[0]] = kv[1]
+		// the value may contain a '=' itself
+		if k, v, ok := strings.Cut(kvPair, "="); ok {
+			rtn[k] = v
 		}
 	}
 	return rtn
@@ -187,3 +187,49 @@ func TestSaveState(t *testing.T) {
 
 	assert.Equal(t, "state-value", rc.IntraActionState["step"]["state-name"])
 }
+
+func TestStrictWorkflowCommands(t *testing.T) {
+	table := []struct {
+		line string
+		err  string
+	}{
+		{"::error file=::missing file\n", "invalid workflow command '::error file=::missing file': malformed property 'file='"},
+		{"::warning line=ten::bad line\n", "invalid workflow command '::warning line=ten::bad line': property 'line' is not a number"},
+		{"::notice colour=red::unknown property\n", "invalid workflow command '::notice colour=red::unknown property': unknown property 'colour'"},
+		{"::set-output::value\n", "invalid workflow command '::set-output::value': missing property 'name'"},
+		{"::unknown-command::arg\n", "invalid workflow command '::unknown-command::arg': unknown command"},
+		{"::error file=main.go,line=10,col=5,title=Lint::unused variable\n", ""},
+		{"::group::build\n", ""},
+		{"::notice title=a=b::equals sign in a value\n", ""},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.line, func(t *testing.T) {
+			rc := &RunContext{
+				Config:      &Config{StrictWorkflowCommands: true},
+				StepResults: map[string]*model.StepResult{},
+			}
+			handler := rc.commandHandler(context.Background())
+			handler(tt.line)
+
+			if tt.err == "" {
+				assert.NoError(t, rc.workflowCommandErr)
+			} else {
+				assert.EqualError(t, rc.workflowCommandErr, tt.err)
+			}
+
+			// commands are lenient by default
+			rc = &RunContext{
+				Config:      &Config{},
+				StepResults: map[string]*model.StepResult{},
+			}
+			handler = rc.commandHandler(context.Background())
+			handler(tt.line)
+			assert.NoError(t, rc.workflowCommandErr)
+		})
+	}
+}
+
+func TestParseKeyValuePairs(t *testing.T) {
+	assert.Equal(t, map[string]string{"title": "a=b", "file": "main.go"}, parseKeyValuePairs("title=a=b,file=main.go,invalid", ","))
+}
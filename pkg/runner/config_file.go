@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the layout of a file of defaults read by LoadConfigFile
+type configFile struct {
+	Actor         string            `yaml:"actor"`
+	DefaultBranch string            `yaml:"default-branch"`
+	Event         string            `yaml:"event"`
+	EventPath     string            `yaml:"event-path"`
+	Platforms     map[string]string `yaml:"platforms"`
+	Bind          bool              `yaml:"bind"`
+	Env           map[string]string `yaml:"env"`
+	EnvFile       string            `yaml:"env-file"`
+	SecretFile    string            `yaml:"secret-file"`
+	VarFile       string            `yaml:"var-file"`
+}
+
+// LoadConfigFile reads a YAML file of defaults like
+//
+//	event: push
+//	platforms:
+//	  ubuntu-latest: node:16-buster-slim
+//	bind: true
+//	env-file: .env
+//	secret-file: .secrets
+//
+// into a Config. Relative paths are resolved against the directory of the file,
+// the env, secret and var files are read into Env, Secrets and Vars with ReadEnvFile.
+// Use MergeConfig to apply explicit options on top of it.
+func LoadConfigFile(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file configFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file '%s': %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	loadEnvFile := func(p string) (map[string]string, error) {
+		if p == "" {
+			return nil, nil
+		}
+		return ReadEnvFile(resolve(p))
+	}
+
+	config := &Config{
+		Actor:         file.Actor,
+		DefaultBranch: file.DefaultBranch,
+		EventName:     file.Event,
+		EventPath:     resolve(file.EventPath),
+		Platforms:     file.Platforms,
+		BindWorkdir:   file.Bind,
+	}
+	env, err := loadEnvFile(file.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+	config.Env = mergeMaps(env, file.Env)
+	if config.Secrets, err = loadEnvFile(file.SecretFile); err != nil {
+		return nil, err
+	}
+	if config.Vars, err = loadEnvFile(file.VarFile); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ReadEnvFile reads a file of env vars, secrets or vars like the --env-file,
+// --secret-file and --var-file options: a YAML map if its extension is .yml or
+// .yaml, a dotenv file (with comments, export and quoting) otherwise
+func ReadEnvFile(path string) (map[string]string, error) {
+	if ext := filepath.Ext(path); ext == ".yml" || ext == ".yaml" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		env := map[string]string{}
+		if err := yaml.Unmarshal(content, &env); err != nil {
+			return nil, err
+		}
+		return env, nil
+	}
+	return godotenv.Read(path)
+}
+
+// MergeConfig returns a copy of config with the unset options LoadConfigFile
+// knows about taken from defaults. Map options are merged, the keys of config win.
+// BindWorkdir of config only turns bind off with BindWorkdirSet.
+func MergeConfig(defaults *Config, config *Config) *Config {
+	merged := *config
+	if merged.Actor == "" {
+		merged.Actor = defaults.Actor
+	}
+	if merged.DefaultBranch == "" {
+		merged.DefaultBranch = defaults.DefaultBranch
+	}
+	if merged.EventName == "" {
+		merged.EventName = defaults.EventName
+	}
+	if merged.EventPath == "" {
+		merged.EventPath = defaults.EventPath
+	}
+	if !merged.BindWorkdirSet {
+		merged.BindWorkdir = merged.BindWorkdir || defaults.BindWorkdir
+	}
+	merged.Platforms = mergeMaps(defaults.Platforms, config.Platforms)
+	merged.Env = mergeMaps(defaults.Env, config.Env)
+	merged.Secrets = mergeMaps(defaults.Secrets, config.Secrets)
+	merged.Vars = mergeMaps(defaults.Vars, config.Vars)
+	return &merged
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	// the files are parsed like the CLI does, with comments, export and quotes
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("# defaults\nexport FROM_FILE=env\nSHARED=file\nQUOTED=\"a b\"\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "secrets.yml"), []byte("TOKEN: secret\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "act.yml"), []byte(`
+actor: octocat
+default-branch: main
+event: pull_request
+event-path: event.json
+bind: true
+platforms:
+  ubuntu-latest: node:16-buster-slim
+  self-hosted: -self-hosted
+env:
+  SHARED: inline
+env-file: .env
+secret-file: secrets.yml
+`), 0o600))
+
+	config, err := LoadConfigFile(filepath.Join(dir, "act.yml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &Config{
+		Actor:         "octocat",
+		DefaultBranch: "main",
+		EventName:     "pull_request",
+		EventPath:     filepath.Join(dir, "event.json"),
+		BindWorkdir:   true,
+		Platforms: map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+			"self-hosted":   "-self-hosted",
+		},
+		Env: map[string]string{
+			"FROM_FILE": "env",
+			"SHARED":    "inline",
+			"QUOTED":    "a b",
+		},
+		Secrets: map[string]string{
+			"TOKEN": "secret",
+		},
+	}, config)
+
+	merged := MergeConfig(config, &Config{
+		EventName: "push",
+		Platforms: map[string]string{"ubuntu-latest": "catthehacker/ubuntu:act-latest"},
+		Env:       map[string]string{"SHARED": "explicit"},
+	})
+	assert.Equal(t, "push", merged.EventName)
+	assert.Equal(t, "octocat", merged.Actor)
+	assert.True(t, merged.BindWorkdir)
+	assert.Equal(t, map[string]string{
+		"ubuntu-latest": "catthehacker/ubuntu:act-latest",
+		"self-hosted":   "-self-hosted",
+	}, merged.Platforms)
+	assert.Equal(t, map[string]string{
+		"FROM_FILE": "env",
+		"SHARED":    "explicit",
+		"QUOTED":    "a b",
+	}, merged.Env)
+	assert.Equal(t, "secret", merged.Secrets["TOKEN"])
+
+	// an explicit option wins over the file, also when it turns bind off
+	assert.False(t, MergeConfig(config, &Config{BindWorkdirSet: true}).BindWorkdir)
+	assert.True(t, MergeConfig(&Config{}, &Config{BindWorkdir: true, BindWorkdirSet: true}).BindWorkdir)
+
+	_, err = LoadConfigFile(filepath.Join(dir, "missing.yml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
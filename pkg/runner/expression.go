@@ -32,6 +32,12 @@ func (rc *RunContext) NewExpressionEvaluator(ctx context.Context) ExpressionEval
 }
 
 func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map[string]string) ExpressionEvaluator {
+	return rc.newExpressionEvaluator(ctx, env, true)
+}
+
+// newExpressionEvaluator creates a new evaluator, without the secrets context
+// unless withSecrets is set
+func (rc *RunContext) newExpressionEvaluator(ctx context.Context, env map[string]string, withSecrets bool) ExpressionEvaluator {
 	var workflowCallResult map[string]*model.WorkflowCallResult
 
 	// todo: cleanup EvaluationEnvironment creation
@@ -45,14 +51,7 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 		}
 
 		jobs := rc.Run.Workflow.Jobs
-		jobNeeds := rc.Run.Job().Needs()
-
-		for _, needs := range jobNeeds {
-			using[needs] = exprparser.Needs{
-				Outputs: jobs[needs].Outputs,
-				Result:  jobs[needs].Result,
-			}
-		}
+		using = rc.getNeedsContext()
 
 		// only setup jobs context in case of workflow_call
 		// and existing expression evaluator (this means, jobs are at
@@ -78,12 +77,11 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 	ee := &exprparser.EvaluationEnvironment{
 		Github: ghc,
 		Env:    env,
-		Job:    rc.getJobContext(),
+		Job:    rc.getJobContext(ctx),
 		Jobs:   &workflowCallResult,
 		// todo: should be unavailable
 		// but required to interpolate/evaluate the step outputs on the job
 		Steps:     rc.getStepsContext(),
-		Secrets:   getWorkflowSecrets(ctx, rc),
 		Vars:      getWorkflowVars(ctx, rc),
 		Strategy:  strategy,
 		Matrix:    rc.Matrix,
@@ -91,6 +89,10 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 		Inputs:    inputs,
 		HashFiles: getHashFilesFunction(ctx, rc),
 	}
+	if withSecrets {
+		ee.Secrets = getWorkflowSecrets(ctx, rc)
+		ee.SecretLookup = rc.secretLookup()
+	}
 	if rc.JobContainer != nil {
 		ee.Runner = rc.JobContainer.GetRunnerContext(ctx)
 	}
@@ -103,9 +105,52 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 	}
 }
 
+// getNeedsContext builds the needs context of the current job. Jobs which
+// are missing or were skipped expose empty outputs, so that property access
+// on them evaluates to an empty value instead of an unevaluated expression.
+func (rc *RunContext) getNeedsContext() map[string]exprparser.Needs {
+	using := make(map[string]exprparser.Needs)
+	job := rc.Run.Job()
+	if job == nil {
+		return using
+	}
+	jobs := rc.Run.Workflow.Jobs
+	for _, needs := range job.Needs() {
+		need, ok := jobs[needs]
+		if !ok || need == nil {
+			using[needs] = exprparser.Needs{Outputs: map[string]string{}}
+			continue
+		}
+		outputs := need.Outputs
+		if need.Result == "skipped" || outputs == nil {
+			outputs = map[string]string{}
+		}
+		using[needs] = exprparser.Needs{
+			Outputs: outputs,
+			Result:  need.Result,
+		}
+	}
+	return using
+}
+
 //go:embed hashfiles/index.js
 var hashfiles string
 
+// getStepEnvContext returns the env context of a step, it includes the paths added
+// by earlier steps via GITHUB_PATH. The env the step runs with is left alone, the
+// paths are applied before it executes in the job container.
+func (rc *RunContext) getStepEnvContext(ctx context.Context, step step) map[string]string {
+	if len(rc.ExtraPath) == 0 || rc.JobContainer == nil {
+		return *step.getEnv()
+	}
+	env := make(map[string]string, len(*step.getEnv()))
+	for k, v := range *step.getEnv() {
+		env[k] = v
+	}
+	rc.ApplyExtraPath(ctx, &env)
+	return env
+}
+
 // NewStepExpressionEvaluator creates a new evaluator
 func (rc *RunContext) NewStepExpressionEvaluator(ctx context.Context, step step) ExpressionEvaluator {
 	// todo: cleanup EvaluationEnvironment creation
@@ -116,24 +161,15 @@ func (rc *RunContext) NewStepExpressionEvaluator(ctx context.Context, step step)
 		strategy["max-parallel"] = job.Strategy.MaxParallel
 	}
 
-	jobs := rc.Run.Workflow.Jobs
-	jobNeeds := rc.Run.Job().Needs()
-
-	using := make(map[string]exprparser.Needs)
-	for _, needs := range jobNeeds {
-		using[needs] = exprparser.Needs{
-			Outputs: jobs[needs].Outputs,
-			Result:  jobs[needs].Result,
-		}
-	}
+	using := rc.getNeedsContext()
 
 	ghc := rc.getGithubContext(ctx)
 	inputs := getEvaluatorInputs(ctx, rc, step, ghc)
 
 	ee := &exprparser.EvaluationEnvironment{
 		Github:   step.getGithubContext(ctx),
-		Env:      *step.getEnv(),
-		Job:      rc.getJobContext(),
+		Env:      rc.getStepEnvContext(ctx, step),
+		Job:      rc.getJobContext(ctx),
 		Steps:    rc.getStepsContext(),
 		Secrets:  getWorkflowSecrets(ctx, rc),
 		Vars:     getWorkflowVars(ctx, rc),
@@ -142,8 +178,9 @@ func (rc *RunContext) NewStepExpressionEvaluator(ctx context.Context, step step)
 		Needs:    using,
 		// todo: should be unavailable
 		// but required to interpolate/evaluate the inputs in actions/composite
-		Inputs:    inputs,
-		HashFiles: getHashFilesFunction(ctx, rc),
+		Inputs:       inputs,
+		HashFiles:    getHashFilesFunction(ctx, rc),
+		SecretLookup: rc.secretLookup(),
 	}
 	if rc.JobContainer != nil {
 		ee.Runner = rc.JobContainer.GetRunnerContext(ctx)
@@ -189,15 +226,18 @@ func getHashFilesFunction(ctx context.Context, rc *RunContext) func(v []reflect.
 			if followSymlink {
 				env["followSymbolicLinks"] = "true"
 			}
+			// resolve the patterns against the workspace as seen by the job container
+			workspace := rc.containerWorkspace()
+			env["GITHUB_WORKSPACE"] = workspace
 
 			stdout, stderr := rc.JobContainer.ReplaceLogWriter(hout, herr)
-			_ = rc.JobContainer.Copy(rc.JobContainer.GetActPath(), &container.FileEntry{
+			_ = rc.copyToJobContainer(rc.JobContainer.GetActPath(), &container.FileEntry{
 				Name: name,
 				Mode: 0o644,
 				Body: hashfiles,
 			}).
 				Then(rc.execJobContainer([]string{"node", path.Join(rc.JobContainer.GetActPath(), name)},
-					env, "", "")).
+					env, "", workspace)).
 				Finally(func(context.Context) error {
 					rc.JobContainer.ReplaceLogWriter(stdout, stderr)
 					return nil
@@ -573,6 +613,17 @@ func getWorkflowSecrets(ctx context.Context, rc *RunContext) map[string]string {
 		return secrets
 	}
 
+	if envSecrets := rc.Config.EnvironmentSecrets[rc.deploymentEnvironmentName]; len(envSecrets) > 0 {
+		secrets := make(map[string]string, len(rc.Config.Secrets)+len(envSecrets))
+		for k, v := range rc.Config.Secrets {
+			secrets[k] = v
+		}
+		for k, v := range envSecrets {
+			secrets[k] = v
+		}
+		return secrets
+	}
+
 	return rc.Config.Secrets
 }
 
@@ -3,14 +3,20 @@ package runner
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
 	"testing"
 
+	"github.com/nektos/act/pkg/container"
 	"github.com/nektos/act/pkg/exprparser"
 	"github.com/nektos/act/pkg/model"
 	assert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	yaml "gopkg.in/yaml.v3"
 )
 
@@ -153,6 +159,68 @@ func TestEvaluateRunContext(t *testing.T) {
 	}
 }
 
+func TestEvaluateGithubContext(t *testing.T) {
+	t.Run("configured", func(t *testing.T) {
+		rc := createRunContext(t)
+		rc.Config.EventName = "pull_request"
+		rc.Config.Env = map[string]string{
+			"GITHUB_REPOSITORY":       "octo-org/octo-repo",
+			"GITHUB_REPOSITORY_OWNER": "octo-owner",
+			"GITHUB_REF":              "refs/pull/42/merge",
+			"GITHUB_SHA":              "0123456789abcdef0123456789abcdef01234567",
+		}
+		rc.EventJSON = `{"pull_request": {"base": {"ref": "main"}, "head": {"ref": "feature"}}}`
+		ee := rc.NewExpressionEvaluator(context.Background())
+
+		for in, out := range map[string]string{
+			"github.repository":       "octo-org/octo-repo",
+			"github.repository_owner": "octo-owner",
+			"github.ref":              "refs/pull/42/merge",
+			"github.ref_name":         "42/merge",
+			"github.sha":              "0123456789abcdef0123456789abcdef01234567",
+			"github.base_ref":         "main",
+			"github.head_ref":         "feature",
+		} {
+			assert.Equal(t, out, ee.Interpolate(context.Background(), "${{ "+in+" }}"), in)
+		}
+	})
+
+	t.Run("SHA_REF", func(t *testing.T) {
+		rc := createRunContext(t)
+		rc.Config.Env = map[string]string{
+			"GITHUB_REPOSITORY": "octo-org/octo-repo",
+			"GITHUB_REF":        "refs/tags/v1.0.0",
+			"SHA_REF":           "abc123",
+		}
+		ee := rc.NewExpressionEvaluator(context.Background())
+
+		assert.Equal(t, "abc123", ee.Interpolate(context.Background(), "${{ github.sha }}"))
+		assert.Equal(t, "v1.0.0", ee.Interpolate(context.Background(), "${{ github.ref_name }}"))
+		assert.Equal(t, "octo-org", ee.Interpolate(context.Background(), "${{ github.repository_owner }}"))
+	})
+
+	t.Run("local repository", func(t *testing.T) {
+		// without configuration the values are derived from the git repository act runs in
+		rc := createRunContext(t)
+		ghc := rc.getGithubContext(context.Background())
+		ee := rc.NewExpressionEvaluator(context.Background())
+
+		assert.Equal(t, ghc.Sha, ee.Interpolate(context.Background(), "${{ github.sha }}"))
+		assert.Equal(t, ghc.Ref, ee.Interpolate(context.Background(), "${{ github.ref }}"))
+		assert.NotEmpty(t, ghc.Ref)
+	})
+
+	t.Run("workspace", func(t *testing.T) {
+		// before the job container exists the workspace is where the workdir gets mounted
+		rc := createRunContext(t)
+		rc.Config.Workdir = "/home/user/project"
+		ee := rc.NewExpressionEvaluator(context.Background())
+
+		assert.NotEmpty(t, ee.Interpolate(context.Background(), "${{ github.workspace }}"))
+		assert.Equal(t, "/home/user/project", ee.Interpolate(context.Background(), "${{ github.workspace }}"))
+	})
+}
+
 func TestEvaluateStep(t *testing.T) {
 	rc := createRunContext(t)
 	step := &stepRun{
@@ -193,6 +261,42 @@ func TestEvaluateStep(t *testing.T) {
 	}
 }
 
+func TestEvaluateStepStatusFunctions(t *testing.T) {
+	rc := createRunContext(t)
+	step := &stepRun{
+		RunContext: rc,
+	}
+
+	evaluate := func(ctx context.Context, in string) interface{} {
+		out, err := rc.NewStepExpressionEvaluator(ctx, step).evaluate(ctx, in, exprparser.DefaultStatusCheckNone)
+		assert.NoError(t, err, in)
+		return out
+	}
+
+	ctx := context.Background()
+	assert.Equal(t, true, evaluate(ctx, "success()"))
+	assert.Equal(t, false, evaluate(ctx, "failure()"))
+	assert.Equal(t, false, evaluate(ctx, "cancelled()"))
+	assert.Equal(t, true, evaluate(ctx, "always()"))
+
+	rc.StepResults["failed"] = &model.StepResult{
+		Conclusion: model.StepStatusFailure,
+		Outcome:    model.StepStatusFailure,
+	}
+	assert.Equal(t, false, evaluate(ctx, "success()"))
+	assert.Equal(t, true, evaluate(ctx, "failure()"))
+	assert.Equal(t, false, evaluate(ctx, "cancelled()"))
+	assert.Equal(t, true, evaluate(ctx, "always()"))
+
+	delete(rc.StepResults, "failed")
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Equal(t, false, evaluate(cancelledCtx, "success()"))
+	assert.Equal(t, false, evaluate(cancelledCtx, "failure()"))
+	assert.Equal(t, true, evaluate(cancelledCtx, "cancelled()"))
+	assert.Equal(t, true, evaluate(cancelledCtx, "always()"))
+}
+
 func TestInterpolate(t *testing.T) {
 	rc := &RunContext{
 		Config: &Config{
@@ -374,3 +478,73 @@ func TestRewriteSubExpressionForceFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestHashFilesZeroByteFiles(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("hashFiles of a job runs with node")
+	}
+	workdir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "zero-1.txt"), nil, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "zero-2.txt"), nil, 0o600))
+	actPath := t.TempDir()
+
+	rc := &RunContext{
+		Config: &Config{
+			Workdir: workdir,
+		},
+		Env: map[string]string{"PATH": os.Getenv("PATH")},
+		JobContainer: &container.HostEnvironment{
+			Workdir: workdir,
+			Path:    workdir,
+			ActPath: actPath,
+			StdOut:  io.Discard,
+		},
+	}
+	hashFiles := getHashFilesFunction(context.Background(), rc)
+
+	// like on GitHub, the hash is the sha256 of the sha256 digests of the files
+	for pattern, expected := range map[string]string{
+		"**/nonexistent": "",
+		"zero-1.txt":     "5df6e0e2761359d30a8275058e299fcc0381534545f55cf43e41983f5d4c9456",
+		"zero-*.txt":     "2dba5dbc339e7316aea2683faf839c1b7b1ee2313db792112588118df066aa35",
+	} {
+		hash, err := hashFiles([]reflect.Value{reflect.ValueOf(pattern)})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, hash, pattern)
+	}
+}
+
+func TestHashFilesWorkspace(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			Workdir: "/home/user/project",
+		},
+		Env: map[string]string{},
+	}
+
+	// on the host the workspace is the copy of the workdir
+	rc.JobContainer = &container.HostEnvironment{
+		Workdir: "/home/user/project",
+		Path:    "/tmp/act/hostexecutor",
+	}
+	assert.Equal(t, "/tmp/act/hostexecutor", rc.containerWorkspace())
+
+	// in a container job the patterns are resolved inside the container workspace
+	cm := &containerMock{}
+	rc.JobContainer = cm
+	assert.Equal(t, "/home/user/project", rc.containerWorkspace())
+
+	cm.On("ReplaceLogWriter", mock.Anything, mock.Anything).Return(io.Discard, io.Discard)
+	cm.On("Copy", "/var/run/act", mock.AnythingOfType("[]*container.FileEntry")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("Exec", []string{"node", "/var/run/act/workflow/hashfiles/index.js"}, mock.MatchedBy(func(env map[string]string) bool {
+		return env["GITHUB_WORKSPACE"] == "/home/user/project" && env["patterns"] == "**/go.sum"
+	}), "", "/home/user/project").Return(func(ctx context.Context) error {
+		return nil
+	})
+
+	_, err := getHashFilesFunction(context.Background(), rc)([]reflect.Value{reflect.ValueOf("**/go.sum")})
+	assert.NoError(t, err)
+	cm.AssertExpectations(t)
+}
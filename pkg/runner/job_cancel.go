@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"context"
+	"sync"
+)
+
+// jobCancels keeps the cancel functions of the running jobs of a runner by
+// their id, it is shared by all RunContexts of the runner so any of them can
+// cancel a job. A called workflow has its own, the job ids are only unique
+// within a workflow and cancelling the calling job cancels its jobs.
+type jobCancels struct {
+	mu        sync.Mutex
+	running   map[string]map[*runningJob]bool // the legs of a matrix share the job id
+	cancelled map[string]bool                 // the pending legs of a cancelled job don't run
+}
+
+type runningJob struct {
+	cancel context.CancelFunc
+}
+
+func newJobCancels() *jobCancels {
+	return &jobCancels{
+		running:   map[string]map[*runningJob]bool{},
+		cancelled: map[string]bool{},
+	}
+}
+
+// add registers the cancel function of a started job, the returned function
+// removes it once the job is done
+func (jc *jobCancels) add(jobID string, cancel context.CancelFunc) func() {
+	job := &runningJob{cancel: cancel}
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	if jc.running[jobID] == nil {
+		jc.running[jobID] = map[*runningJob]bool{}
+	}
+	jc.running[jobID][job] = true
+	return func() {
+		jc.mu.Lock()
+		defer jc.mu.Unlock()
+		delete(jc.running[jobID], job)
+		job.cancel()
+	}
+}
+
+// cancel cancels every running leg of the job and reports whether there was any
+func (jc *jobCancels) cancel(jobID string) bool {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	if len(jc.running[jobID]) == 0 {
+		return false
+	}
+	jc.cancelled[jobID] = true
+	for job := range jc.running[jobID] {
+		job.cancel()
+	}
+	return true
+}
+
+func (jc *jobCancels) isCancelled(jobID string) bool {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	return jc.cancelled[jobID]
+}
+
+// done forgets the cancellation of the job once all of its legs ran
+func (jc *jobCancels) done(jobID string) {
+	if jc == nil {
+		return
+	}
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	delete(jc.cancelled, jobID)
+}
@@ -2,10 +2,13 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/exprparser"
 	"github.com/nektos/act/pkg/model"
 )
 
@@ -53,15 +56,29 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 		return nil
 	})
 
+	stepIDs := map[string]bool{}
+	for _, stepModel := range infoSteps {
+		if stepModel != nil && stepModel.ID != "" {
+			stepIDs[stepModel.ID] = true
+		}
+	}
+
 	for i, stepModel := range infoSteps {
-		stepModel := stepModel
 		if stepModel == nil {
 			return func(ctx context.Context) error {
 				return fmt.Errorf("invalid Step %v: missing run or uses key", i)
 			}
 		}
+		// the legs of a matrix job share the steps of the job, each works on a copy
+		stepCopy := *stepModel
+		stepModel := &stepCopy
 		if stepModel.ID == "" {
-			stepModel.ID = fmt.Sprintf("%d", i)
+			stepModel.ID = defaultStepID(stepIDs, i)
+		}
+
+		if rc.Config.StepFilter != nil && !rc.Config.StepFilter(stepModel, i) {
+			steps = append(steps, useStepLogger(rc, stepModel, stepStageMain, skipStepExecutor(rc, stepModel)))
+			continue
 		}
 
 		step, err := sf.newStep(stepModel, rc)
@@ -95,10 +112,16 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 		}
 	}
 
+	if postExecutor == nil {
+		// all steps were skipped by the step filter
+		postExecutor = func(ctx context.Context) error { return nil }
+	}
+
 	postExecutor = postExecutor.Finally(func(ctx context.Context) error {
 		jobError := common.JobError(ctx)
 		var err error
-		if rc.Config.AutoRemove || jobError == nil {
+		cancelled := rc.jobCancelled() || errors.Is(jobError, context.Canceled)
+		if rc.Config.AutoRemove || jobError == nil || cancelled {
 			// always allow 1 min for stopping and removing the runner, even if we were cancelled
 			ctx, cancel := context.WithTimeout(common.WithLogger(context.Background(), common.Logger(ctx)), time.Minute)
 			defer cancel()
@@ -109,7 +132,7 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 				logger.Errorf("Error while stop job container: %v", err)
 			}
 		}
-		setJobResult(ctx, info, rc, jobError == nil)
+		setJobResult(ctx, info, rc, jobError == nil, cancelled)
 		setJobOutputs(ctx, rc)
 
 		return err
@@ -124,8 +147,8 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 			var cancel context.CancelFunc
 			if ctx.Err() == context.Canceled {
 				// in case of an aborted run, we still should execute the
-				// post steps to allow cleanup.
-				ctx, cancel = context.WithTimeout(common.WithLogger(context.Background(), common.Logger(ctx)), 5*time.Minute)
+				// post steps to allow cleanup. The job error is kept for the job result.
+				ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), 5*time.Minute)
 				defer cancel()
 			}
 			return postExecutor(ctx)
@@ -134,18 +157,43 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 		Finally(info.closeContainer()))
 }
 
-func setJobResult(ctx context.Context, info jobInfo, rc *RunContext, success bool) {
-	logger := common.Logger(ctx)
+func isJobContinueOnError(ctx context.Context, rc *RunContext) bool {
+	job := rc.Run.Job()
+	if job == nil || strings.TrimSpace(job.ContinueOnError()) == "" {
+		return false
+	}
+	continueOnError, err := EvalBool(ctx, rc.ExprEval, job.ContinueOnError(), exprparser.DefaultStatusCheckNone)
+	if err != nil {
+		common.Logger(ctx).Errorf("Error in continue-on-error-expression: \"continue-on-error: %s\" (%s)", job.ContinueOnError(), err)
+		return false
+	}
+	return continueOnError
+}
 
-	jobResult := "success"
-	// we have only one result for a whole matrix build, so we need
-	// to keep an existing result state if we run a matrix
-	if len(info.matrix()) > 0 && rc.Run.Job().Result != "" {
-		jobResult = rc.Run.Job().Result
+func setJobResult(ctx context.Context, info jobInfo, rc *RunContext, success bool, cancelled bool) {
+	logger := common.Logger(ctx).WithField("duration", rc.stepsDuration())
+
+	legResult := "success"
+	if !success && cancelled {
+		legResult = "cancelled"
+	} else if !success {
+		legResult = "failure"
+		// the job keeps its failure, only the run doesn't fail because of it
+		continueOnError := isJobContinueOnError(ctx, rc)
+		if continueOnError {
+			logger.WithField("jobResult", legResult).Warnf("\U0001F3C1  Job failed, but the run continues because of continue-on-error")
+		}
+		allowJobFailure(rc.Run.Job(), continueOnError)
+		if rc.caller != nil {
+			allowJobFailure(rc.caller.runContext.Run.Job(), continueOnError)
+		}
 	}
+	rc.conclusion = legResult
 
-	if !success {
-		jobResult = "failure"
+	jobResult := legResult
+	// we have only one result for a whole matrix build, it combines the legs
+	if len(info.matrix()) > 0 {
+		jobResult = combineJobResults(rc.Run.Job().Result, legResult)
 	}
 
 	info.result(jobResult)
@@ -155,11 +203,40 @@ func setJobResult(ctx context.Context, info jobInfo, rc *RunContext, success boo
 	}
 
 	jobResultMessage := "succeeded"
-	if jobResult != "success" {
+	if legResult == "cancelled" {
+		jobResultMessage = "cancelled"
+	} else if legResult != "success" {
 		jobResultMessage = "failed"
 	}
 
-	logger.WithField("jobResult", jobResult).Infof("\U0001F3C1  Job %s", jobResultMessage)
+	logger.WithField("jobResult", legResult).Infof("\U0001F3C1  Job %s", jobResultMessage)
+}
+
+// jobResultPrecedence orders the results of the legs of a matrix job, a
+// failed leg fails the job and a cancelled one cancels it otherwise
+var jobResultPrecedence = map[string]int{"success": 1, "cancelled": 2, "failure": 3}
+
+func combineJobResults(a string, b string) string {
+	if jobResultPrecedence[a] > jobResultPrecedence[b] {
+		return a
+	}
+	return b
+}
+
+// allowJobFailure records whether the failure of the job fails the run, it
+// must be called before the failure is set as the result of the job.
+// A failed matrix leg without continue-on-error fails the run for all legs.
+func allowJobFailure(job *model.Job, allowed bool) {
+	job.FailureAllowed = allowed && (job.Result != "failure" || job.FailureAllowed)
+}
+
+// stepsDuration is the time spent running the main executors of the job's steps
+func (rc *RunContext) stepsDuration() time.Duration {
+	var total time.Duration
+	for _, stepResult := range rc.StepResults {
+		total += stepResult.Duration
+	}
+	return total
 }
 
 func setJobOutputs(ctx context.Context, rc *RunContext) {
@@ -177,19 +254,27 @@ func setJobOutputs(ctx context.Context, rc *RunContext) {
 	}
 }
 
+// skipStepExecutor marks a step excluded by the step filter as skipped without running it
+func skipStepExecutor(rc *RunContext, stepModel *model.Step) common.Executor {
+	return func(ctx context.Context) error {
+		if rc.StepResults == nil {
+			rc.StepResults = map[string]*model.StepResult{}
+		}
+		rc.StepResults[stepModel.ID] = &model.StepResult{
+			Outcome:    model.StepStatusSkipped,
+			Conclusion: model.StepStatusSkipped,
+			Outputs:    make(map[string]string),
+		}
+		common.Logger(ctx).WithField("stepResult", model.StepStatusSkipped).Debugf("Skipping step '%s' due to the step filter", stepModel)
+		return nil
+	}
+}
+
 func useStepLogger(rc *RunContext, stepModel *model.Step, stage stepStage, executor common.Executor) common.Executor {
 	return func(ctx context.Context) error {
 		ctx = withStepLogger(ctx, stepModel.ID, rc.ExprEval.Interpolate(ctx, stepModel.String()), stage.String())
 
-		rawLogger := common.Logger(ctx).WithField("raw_output", true)
-		logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-			if rc.Config.LogOutput {
-				rawLogger.Infof("%s", s)
-			} else {
-				rawLogger.Debugf("%s", s)
-			}
-			return true
-		})
+		logWriter := rc.newLogWriter(ctx)
 
 		oldout, olderr := rc.JobContainer.ReplaceLogWriter(logWriter, logWriter)
 		defer rc.JobContainer.ReplaceLogWriter(oldout, olderr)
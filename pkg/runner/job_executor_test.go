@@ -104,6 +104,8 @@ func TestNewJobExecutor(t *testing.T) {
 		executedSteps []string
 		result        string
 		hasError      bool
+		stepFilter    func(*model.Step, int) bool
+		continueOn    string
 	}{
 		{
 			name:          "zeroSteps",
@@ -229,6 +231,49 @@ func TestNewJobExecutor(t *testing.T) {
 			result:   "success",
 			hasError: false,
 		},
+		{
+			name: "stepsFiltered",
+			steps: []*model.Step{{
+				ID: "1",
+			}, {
+				ID: "2",
+			}, {
+				ID: "3",
+			}},
+			preSteps:  []bool{true, true, true},
+			postSteps: []bool{true, true, true},
+			executedSteps: []string{
+				"startContainer",
+				"pre2",
+				"step2",
+				"post2",
+				"stopContainer",
+				"interpolateOutputs",
+				"closeContainer",
+			},
+			result:   "success",
+			hasError: false,
+			stepFilter: func(_ *model.Step, i int) bool {
+				return i == 1
+			},
+		},
+		{
+			name: "stepWithFailureContinueOnError",
+			steps: []*model.Step{{
+				ID: "1",
+			}},
+			preSteps:  []bool{false},
+			postSteps: []bool{false},
+			executedSteps: []string{
+				"startContainer",
+				"step1",
+				"interpolateOutputs",
+				"closeContainer",
+			},
+			result:     "failure",
+			hasError:   true,
+			continueOn: "${{ matrix.experimental }}",
+		},
 	}
 
 	contains := func(needle string, haystack []string) bool {
@@ -253,11 +298,18 @@ func TestNewJobExecutor(t *testing.T) {
 					JobID: "test",
 					Workflow: &model.Workflow{
 						Jobs: map[string]*model.Job{
-							"test": {},
+							"test": {
+								RawContinueOnError: tt.continueOn,
+							},
 						},
 					},
 				},
-				Config: &Config{},
+				Config: &Config{
+					StepFilter: tt.stepFilter,
+				},
+				Matrix: map[string]interface{}{
+					"experimental": true,
+				},
 			}
 			rc.ExprEval = rc.NewExpressionEvaluator(ctx)
 			executorOrder := make([]string, 0)
@@ -275,6 +327,10 @@ func TestNewJobExecutor(t *testing.T) {
 				i := i
 				stepModel := stepModel
 
+				if tt.stepFilter != nil && !tt.stepFilter(stepModel, i) {
+					continue
+				}
+
 				sm := &stepMock{}
 
 				sfm.On("newStep", stepModel, rc).Return(sm, nil)
@@ -331,6 +387,16 @@ func TestNewJobExecutor(t *testing.T) {
 			err := executor(ctx)
 			assert.Nil(t, err)
 			assert.Equal(t, tt.executedSteps, executorOrder)
+			if tt.stepFilter != nil {
+				for i, stepModel := range tt.steps {
+					if !tt.stepFilter(stepModel, i) {
+						assert.Equal(t, model.StepStatusSkipped, rc.StepResults[stepModel.ID].Conclusion)
+						assert.Empty(t, rc.StepResults[stepModel.ID].Outputs)
+					}
+				}
+			}
+
+			assert.Equal(t, tt.continueOn != "", rc.Run.Job().FailureAllowed)
 
 			jim.AssertExpectations(t)
 			sfm.AssertExpectations(t)
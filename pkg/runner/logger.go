@@ -8,6 +8,8 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/nektos/act/pkg/common"
 
@@ -87,6 +89,7 @@ func WithJobLogger(ctx context.Context, jobID string, jobName string, config *Co
 			formatter = &jobLogFormatter{
 				color:          colors[nextColor%len(colors)],
 				logPrefixJobID: config.LogPrefixJobID,
+				showTimestamps: config.ShowTimestamps,
 			}
 		}
 
@@ -95,6 +98,9 @@ func WithJobLogger(ctx context.Context, jobID string, jobName string, config *Co
 		logger.SetLevel(logrus.GetLevel())
 		logger.SetFormatter(formatter)
 	}
+	if level, ok := config.JobLogLevels[jobID]; ok {
+		logger.SetLevel(level)
+	}
 
 	logger.SetFormatter(&maskedFormatter{
 		Formatter: logger.Formatter,
@@ -149,22 +155,45 @@ func valueMasker(insecureSecrets bool, secrets map[string]string) entryProcessor
 			return entry
 		}
 
-		masks := Masks(entry.Context)
+		entry.Message = maskValues(entry.Message, secrets, *Masks(entry.Context))
 
-		for _, v := range secrets {
-			if v != "" {
-				entry.Message = strings.ReplaceAll(entry.Message, v, "***")
-			}
+		return entry
+	}
+}
+
+// maskValues replaces the values of the secrets and masks in s with ***
+func maskValues(s string, secrets map[string]string, masks []string) string {
+	for _, v := range secrets {
+		if v != "" {
+			s = strings.ReplaceAll(s, v, "***")
 		}
+	}
 
-		for _, v := range *masks {
-			if v != "" {
-				entry.Message = strings.ReplaceAll(entry.Message, v, "***")
-			}
+	for _, v := range masks {
+		if v != "" {
+			s = strings.ReplaceAll(s, v, "***")
 		}
+	}
 
-		return entry
+	return s
+}
+
+// truncateLogLine cuts a line longer than limit bytes at a rune boundary and
+// marks it as truncated, a trailing line break is kept
+func truncateLogLine(s string, limit int) string {
+	line, eol := strings.CutSuffix(s, "\n")
+	if limit <= 0 || len(line) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
 	}
+	s = line[:cut] + "…[truncated]"
+	if eol {
+		s += "\n"
+	}
+	return s
 }
 
 type maskedFormatter struct {
@@ -179,6 +208,7 @@ func (f *maskedFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 type jobLogFormatter struct {
 	color          int
 	logPrefixJobID bool
+	showTimestamps bool
 }
 
 func (f *jobLogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
@@ -210,7 +240,7 @@ func (f *jobLogFormatter) printColored(b *bytes.Buffer, entry *logrus.Entry) {
 	}
 
 	if entry.Data["raw_output"] == true {
-		fmt.Fprintf(b, "\x1b[%dm|\x1b[0m %s", f.color, entry.Message)
+		fmt.Fprintf(b, "\x1b[%dm|\x1b[0m %s%s", f.color, f.timestamp(entry), entry.Message)
 	} else if entry.Data["dryrun"] == true {
 		fmt.Fprintf(b, "\x1b[1m\x1b[%dm\x1b[7m*DRYRUN*\x1b[0m \x1b[%dm[%s] \x1b[0m%s%s", gray, f.color, job, debugFlag, entry.Message)
 	} else {
@@ -234,7 +264,7 @@ func (f *jobLogFormatter) print(b *bytes.Buffer, entry *logrus.Entry) {
 	}
 
 	if entry.Data["raw_output"] == true {
-		fmt.Fprintf(b, "[%s]   | %s", job, entry.Message)
+		fmt.Fprintf(b, "[%s]   | %s%s", job, f.timestamp(entry), entry.Message)
 	} else if entry.Data["dryrun"] == true {
 		fmt.Fprintf(b, "*DRYRUN* [%s] %s%s", job, debugFlag, entry.Message)
 	} else {
@@ -242,6 +272,15 @@ func (f *jobLogFormatter) print(b *bytes.Buffer, entry *logrus.Entry) {
 	}
 }
 
+// timestamp returns the time an output line was logged if timestamps are shown.
+// It is added after the workflow commands of the line were processed.
+func (f *jobLogFormatter) timestamp(entry *logrus.Entry) string {
+	if !f.showTimestamps {
+		return ""
+	}
+	return entry.Time.Format(time.RFC3339) + " "
+}
+
 func (f *jobLogFormatter) isColored(entry *logrus.Entry) bool {
 	isColored := checkIfTerminal(entry.Logger.Out)
 
@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"strings"
+	"sync"
+)
+
+// outputTail is a ring buffer keeping the last lines of the output of a step
+type outputTail struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+func newOutputTail(size int) *outputTail {
+	return &outputTail{lines: make([]string, size)}
+}
+
+func (t *outputTail) add(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines[t.next] = strings.TrimSuffix(line, "\n")
+	t.next = (t.next + 1) % len(t.lines)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+func (t *outputTail) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.next = 0
+	t.full = false
+}
+
+// Lines returns a copy of the kept lines, oldest first
+func (t *outputTail) Lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.full {
+		return append([]string{}, t.lines[:t.next]...)
+	}
+	return append(append([]string{}, t.lines[t.next:]...), t.lines[:t.next]...)
+}
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputTail(t *testing.T) {
+	tail := newOutputTail(3)
+	assert.Equal(t, []string{}, tail.Lines())
+
+	tail.add("line 0\n")
+	tail.add("line 1\n")
+	assert.Equal(t, []string{"line 0", "line 1"}, tail.Lines())
+
+	for i := 2; i < 10; i++ {
+		tail.add(fmt.Sprintf("line %d\n", i))
+	}
+	assert.Equal(t, []string{"line 7", "line 8", "line 9"}, tail.Lines())
+
+	tail.reset()
+	tail.add("next step\n")
+	assert.Equal(t, []string{"next step"}, tail.Lines())
+}
+
+func TestRunContextLogWriterOutputTail(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			StepOutputTail: 2,
+			Secrets:        map[string]string{"TOKEN": "s3cr3t"},
+		},
+		stepOutputTail: newOutputTail(2),
+	}
+	composite := &RunContext{Config: rc.Config, Parent: rc}
+
+	_, _ = io.WriteString(rc.newLogWriter(context.Background()), "one\ntwo\n")
+	_, _ = io.WriteString(composite.newLogWriter(context.Background()), "token s3cr3t\n")
+	assert.Equal(t, []string{"two", "token ***"}, rc.stepOutputTail.Lines())
+}
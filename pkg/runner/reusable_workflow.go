@@ -17,7 +17,8 @@ import (
 )
 
 func newLocalReusableWorkflowExecutor(rc *RunContext) common.Executor {
-	return newReusableWorkflowExecutor(rc, rc.Config.Workdir, rc.Run.Job().Uses)
+	uses := rc.Run.Job().Uses
+	return newReusableWorkflowExecutor(rc, rc.localUsesRoot(uses), uses)
 }
 
 func newRemoteReusableWorkflowExecutor(rc *RunContext) common.Executor {
@@ -141,6 +142,8 @@ func NewReusableWorkflowRunner(rc *RunContext) (Runner, error) {
 		caller: &caller{
 			runContext: rc,
 		},
+		runID:   rc.runID,
+		cancels: newJobCancels(),
 	}
 
 	return runner.configure()
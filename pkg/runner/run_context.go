@@ -11,12 +11,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/docker/go-connections/nat"
 	"github.com/nektos/act/pkg/common"
@@ -47,8 +51,24 @@ type RunContext struct {
 	ActionPath          string
 	Parent              *RunContext
 	Masks               []string
+	Annotations         []Annotation
 	cleanUpJobContainer common.Executor
-	caller              *caller // job calling this RunContext (reusable workflows)
+	shellProbes         map[string]bool   // shell executables found in the job container
+	resolvedSecrets     map[string]string // secrets fetched from Config.SecretResolver by their upper case name
+	workflowCommandErr  error             // first invalid workflow command of the current step with Config.StrictWorkflowCommands
+	stepOutputTail      *outputTail       // last output lines of the current step with Config.StepOutputTail, kept by the job's RunContext
+	serviceIDs          []string          // ids of the services of ServiceContainers, by the same index
+	caller              *caller           // job calling this RunContext (reusable workflows)
+	runID               string            // suffix of container names with Config.UniqueContainerNames
+	jobCancels          *jobCancels       // cancel functions of the running jobs of the run, see CancelJob
+
+	// paths and modes of the files copied into the job container, kept by the job's RunContext
+	copiedFiles []container.FileEntry
+
+	// name of the deployment environment of the job, see resolveDeploymentEnvironmentName
+	deploymentEnvironmentName string
+	// conclusion of the job, of this leg for a matrix job, see setJobResult
+	conclusion string
 }
 
 func (rc *RunContext) AddMask(mask string) {
@@ -70,7 +90,39 @@ func (rc *RunContext) String() string {
 	return name
 }
 
-// GetEnv returns the env for the context
+// DeploymentEnvironment returns the deployment environment of the job with
+// its name and url interpolated, or nil if the job has none
+func (rc *RunContext) DeploymentEnvironment(ctx context.Context) *model.JobEnvironment {
+	job := rc.Run.Job()
+	if job == nil {
+		return nil
+	}
+	env := job.DeploymentEnvironment()
+	if env == nil {
+		return nil
+	}
+	return &model.JobEnvironment{
+		Name: rc.deploymentEnvironmentName,
+		URL:  rc.ExprEval.Interpolate(ctx, env.URL),
+	}
+}
+
+// resolveDeploymentEnvironmentName interpolates the name of the deployment
+// environment of the job, "" if it has none. The name selects the secrets of
+// the job, so it can't reference secrets, the evaluator has none.
+func (rc *RunContext) resolveDeploymentEnvironmentName(ctx context.Context) string {
+	if rc.Run == nil || rc.Run.Job() == nil {
+		return ""
+	}
+	env := rc.Run.Job().DeploymentEnvironment()
+	if env == nil {
+		return ""
+	}
+	return rc.newExpressionEvaluator(ctx, rc.GetEnv(), false).Interpolate(ctx, env.Name)
+}
+
+// GetEnv returns the env for the context. The workflow env forms the base,
+// overridden by the job env and then by the env of the config.
 func (rc *RunContext) GetEnv() map[string]string {
 	if rc.Env == nil {
 		rc.Env = map[string]string{}
@@ -82,11 +134,49 @@ func (rc *RunContext) GetEnv() map[string]string {
 		}
 	}
 	rc.Env["ACT"] = "true"
+	if rc.Config != nil && rc.Config.ArtifactPath != "" {
+		rc.Env["ACT_ARTIFACT_PATH"] = rc.containerPath(rc.Config.ArtifactPath)
+	}
 	return rc.Env
 }
 
+// CollectedArtifacts returns the files below Config.ArtifactPath, relative to
+// it and sorted. Jobs write them there through ACT_ARTIFACT_PATH.
+func (rc *RunContext) CollectedArtifacts() ([]string, error) {
+	if rc.Config.ArtifactPath == "" {
+		return nil, nil
+	}
+	var artifacts []string
+	err := filepath.WalkDir(rc.Config.ArtifactPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(rc.Config.ArtifactPath, p)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, filepath.ToSlash(rel))
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	sort.Strings(artifacts)
+	return artifacts, err
+}
+
+// jobContainerName is the name of the job container, it is the base of the
+// names of its volumes, networks and the containers of services and docker actions.
+// It is stable for a job, with Config.UniqueContainerNames it differs between runs.
 func (rc *RunContext) jobContainerName() string {
-	return createContainerName("act", rc.String())
+	prefix := rc.Config.ContainerNamePrefix
+	if prefix == "" {
+		prefix = "act"
+	}
+	if rc.runID != "" {
+		return createContainerName(prefix, rc.runID, rc.String())
+	}
+	return createContainerName(prefix, rc.String())
 }
 
 // networkName return the name of the network which will be created by `act` automatically for job,
@@ -141,8 +231,9 @@ func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 	}
 
 	if job := rc.Run.Job(); job != nil {
-		if container := job.Container(); container != nil {
-			for _, v := range container.Volumes {
+		if spec := job.Container(); spec != nil {
+			for _, v := range spec.Volumes {
+				v = container.ExpandBindHostPath(v)
 				if !strings.Contains(v, ":") || filepath.IsAbs(v) {
 					// Bind anonymous volume or host file.
 					binds = append(binds, v)
@@ -155,6 +246,10 @@ func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 		}
 	}
 
+	if rc.Config.ArtifactPath != "" {
+		binds = append(binds, fmt.Sprintf("%s:%s", rc.Config.ArtifactPath, ext.ToContainerPath(rc.Config.ArtifactPath)))
+	}
+
 	if rc.Config.BindWorkdir {
 		bindModifiers := ""
 		if runtime.GOOS == "darwin" {
@@ -163,26 +258,62 @@ func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 		if selinux.GetEnabled() {
 			bindModifiers = ":z"
 		}
-		binds = append(binds, fmt.Sprintf("%s:%s%s", rc.Config.Workdir, ext.ToContainerPath(rc.Config.Workdir), bindModifiers))
+		binds = append(binds, fmt.Sprintf("%s:%s%s", rc.Config.Workdir, rc.containerWorkspace(), bindModifiers))
 	} else {
-		mounts[name] = ext.ToContainerPath(rc.Config.Workdir)
+		mounts[name] = rc.containerWorkspace()
 	}
 
 	return binds, mounts
 }
 
-func (rc *RunContext) startHostEnvironment() common.Executor {
-	return func(ctx context.Context) error {
-		logger := common.Logger(ctx)
-		rawLogger := logger.WithField("raw_output", true)
-		logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-			if rc.Config.LogOutput {
-				rawLogger.Infof("%s", s)
-			} else {
-				rawLogger.Debugf("%s", s)
+// outputCaptureMu serializes the lines of parallel jobs written to Config.OutputCapture
+var outputCaptureMu sync.Mutex
+
+// newLogWriter returns the writer for the output of job and step containers.
+// It handles workflow commands and logs the output, which is also copied line by
+// line with masked secrets to Config.OutputCapture if set and kept for the
+// output tail of the current step. Lines longer than Config.MaxLogLineBytes
+// are truncated, workflow commands are handled before.
+func (rc *RunContext) newLogWriter(ctx context.Context) io.Writer {
+	rawLogger := common.Logger(ctx).WithField("raw_output", true)
+	jobRC := rc
+	for jobRC.Parent != nil {
+		jobRC = jobRC.Parent
+	}
+	return common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
+		if rc.Config.MaxLogLineBytes > 0 && len(s) > rc.Config.MaxLogLineBytes {
+			if !rc.Config.InsecureSecrets {
+				// mask first, so no part of a secret is left at the cut
+				s = maskValues(s, rc.Config.Secrets, rc.Masks)
 			}
+			s = truncateLogLine(s, rc.Config.MaxLogLineBytes)
+		}
+		if rc.Config.LogOutput {
+			rawLogger.Infof("%s", s)
+		} else {
+			rawLogger.Debugf("%s", s)
+		}
+		if rc.Config.OutputCapture == nil && jobRC.stepOutputTail == nil {
 			return true
-		})
+		}
+		if !rc.Config.InsecureSecrets {
+			s = maskValues(s, rc.Config.Secrets, rc.Masks)
+		}
+		if jobRC.stepOutputTail != nil {
+			jobRC.stepOutputTail.add(s)
+		}
+		if rc.Config.OutputCapture != nil {
+			outputCaptureMu.Lock()
+			_, _ = io.WriteString(rc.Config.OutputCapture, s)
+			outputCaptureMu.Unlock()
+		}
+		return true
+	})
+}
+
+func (rc *RunContext) startHostEnvironment() common.Executor {
+	return func(ctx context.Context) error {
+		logWriter := rc.newLogWriter(ctx)
 		cacheDir := rc.ActionCacheDir()
 		randBytes := make([]byte, 8)
 		_, _ = rand.Read(randBytes)
@@ -199,6 +330,9 @@ func (rc *RunContext) startHostEnvironment() common.Executor {
 		if err := os.MkdirAll(runnerTmp, 0o777); err != nil {
 			return err
 		}
+		if err := rc.createArtifactPath(); err != nil {
+			return err
+		}
 		toolCache := filepath.Join(cacheDir, "tool_cache")
 		rc.JobContainer = &container.HostEnvironment{
 			Path:      path,
@@ -227,7 +361,7 @@ func (rc *RunContext) startHostEnvironment() common.Executor {
 		}
 
 		return common.NewPipelineExecutor(
-			rc.JobContainer.Copy(rc.JobContainer.GetActPath()+"/", &container.FileEntry{
+			rc.copyToJobContainer(rc.JobContainer.GetActPath()+"/", &container.FileEntry{
 				Name: "workflow/event.json",
 				Mode: 0o644,
 				Body: rc.EventJSON,
@@ -244,16 +378,8 @@ func (rc *RunContext) startHostEnvironment() common.Executor {
 func (rc *RunContext) startJobContainer() common.Executor {
 	return func(ctx context.Context) error {
 		logger := common.Logger(ctx)
-		image := rc.platformImage(ctx)
-		rawLogger := logger.WithField("raw_output", true)
-		logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-			if rc.Config.LogOutput {
-				rawLogger.Infof("%s", s)
-			} else {
-				rawLogger.Debugf("%s", s)
-			}
-			return true
-		})
+		image := container.MirrorImage(rc.platformImage(ctx), rc.Config.RegistryMirrors)
+		logWriter := rc.newLogWriter(ctx)
 
 		username, password, err := rc.handleCredentials(ctx)
 		if err != nil {
@@ -263,6 +389,11 @@ func (rc *RunContext) startJobContainer() common.Executor {
 		logger.Infof("\U0001f680  Start image=%s", image)
 		name := rc.jobContainerName()
 
+		// docker would create a missing bind source owned by root
+		if err := rc.createArtifactPath(); err != nil {
+			return err
+		}
+
 		envList := make([]string, 0)
 
 		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TOOL_CACHE", "/opt/hostedtoolcache"))
@@ -314,7 +445,7 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			c := container.NewContainer(&container.NewContainerInput{
 				Name:           serviceContainerName,
 				WorkingDir:     ext.ToContainerPath(rc.Config.Workdir),
-				Image:          rc.ExprEval.Interpolate(ctx, spec.Image),
+				Image:          container.MirrorImage(rc.ExprEval.Interpolate(ctx, spec.Image), rc.Config.RegistryMirrors),
 				Username:       username,
 				Password:       password,
 				Env:            envs,
@@ -332,6 +463,27 @@ func (rc *RunContext) startJobContainer() common.Executor {
 				PortBindings:   portBindings,
 			})
 			rc.ServiceContainers = append(rc.ServiceContainers, c)
+			rc.serviceIDs = append(rc.serviceIDs, serviceID)
+		}
+
+		cleanUpServices := func(ctx context.Context) error {
+			if len(rc.ServiceContainers) > 0 {
+				logger.Infof("Cleaning up services for job %s", rc.JobName)
+				if err := rc.stopServiceContainers()(ctx); err != nil {
+					logger.Errorf("Error while cleaning services: %v", err)
+				}
+				if createAndDeleteNetwork {
+					// clean network if it has been created by act
+					// if using service containers
+					// it means that the network to which containers are connecting is created by `act_runner`,
+					// so, we should remove the network at last.
+					logger.Infof("Cleaning up network for job %s, and network name is: %s", rc.JobName, networkName)
+					if err := container.NewDockerNetworkRemoveExecutor(networkName)(ctx); err != nil {
+						logger.Errorf("Error while cleaning network: %v", err)
+					}
+				}
+			}
+			return nil
 		}
 
 		rc.cleanUpJobContainer = func(ctx context.Context) error {
@@ -343,25 +495,7 @@ func (rc *RunContext) startJobContainer() common.Executor {
 				return rc.JobContainer.Remove().IfNot(reuseJobContainer).
 					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName(), false)).IfNot(reuseJobContainer).
 					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName()+"-env", false)).IfNot(reuseJobContainer).
-					Then(func(ctx context.Context) error {
-						if len(rc.ServiceContainers) > 0 {
-							logger.Infof("Cleaning up services for job %s", rc.JobName)
-							if err := rc.stopServiceContainers()(ctx); err != nil {
-								logger.Errorf("Error while cleaning services: %v", err)
-							}
-							if createAndDeleteNetwork {
-								// clean network if it has been created by act
-								// if using service containers
-								// it means that the network to which containers are connecting is created by `act_runner`,
-								// so, we should remove the network at last.
-								logger.Infof("Cleaning up network for job %s, and network name is: %s", rc.JobName, networkName)
-								if err := container.NewDockerNetworkRemoveExecutor(networkName)(ctx); err != nil {
-									logger.Errorf("Error while cleaning network: %v", err)
-								}
-							}
-						}
-						return nil
-					})(ctx)
+					Then(cleanUpServices)(ctx)
 			}
 			return nil
 		}
@@ -376,7 +510,7 @@ func (rc *RunContext) startJobContainer() common.Executor {
 		rc.JobContainer = container.NewContainer(&container.NewContainerInput{
 			Cmd:            nil,
 			Entrypoint:     []string{"tail", "-f", "/dev/null"},
-			WorkingDir:     ext.ToContainerPath(rc.Config.Workdir),
+			WorkingDir:     rc.jobContainerWorkingDir(ctx),
 			Image:          image,
 			Username:       username,
 			Password:       password,
@@ -397,7 +531,7 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			return errors.New("Failed to create job container")
 		}
 
-		return common.NewPipelineExecutor(
+		err = common.NewPipelineExecutor(
 			rc.pullServicesImages(rc.Config.ForcePull),
 			rc.JobContainer.Pull(rc.Config.ForcePull),
 			rc.stopJobContainer(),
@@ -405,7 +539,8 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			rc.startServiceContainers(networkName),
 			rc.JobContainer.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
 			rc.JobContainer.Start(false),
-			rc.JobContainer.Copy(rc.JobContainer.GetActPath()+"/", &container.FileEntry{
+			rc.jobContainerStarted(),
+			rc.copyToJobContainer(rc.JobContainer.GetActPath()+"/", &container.FileEntry{
 				Name: "workflow/event.json",
 				Mode: 0o644,
 				Body: rc.EventJSON,
@@ -415,7 +550,108 @@ func (rc *RunContext) startJobContainer() common.Executor {
 				Body: "",
 			}),
 		)(ctx)
+		if err != nil {
+			// the job doesn't run, don't leave its services and network behind. Like
+			// for a failed job the job container is kept unless AutoRemove is set.
+			ctx, cancel := context.WithTimeout(common.WithLogger(context.Background(), logger), time.Minute) //nolint:contextcheck
+			defer cancel()
+			logger.Infof("Cleaning up job %s after a failed start", rc.JobName)
+			cleanUp := cleanUpServices
+			if rc.Config.AutoRemove {
+				cleanUp = rc.stopJobContainer()
+			}
+			if rmErr := cleanUp(ctx); rmErr != nil {
+				logger.Errorf("Error while cleaning up after a failed start: %v", rmErr)
+			}
+		}
+		return err
+	}
+}
+
+// JobContainerID returns the id of the job container once it is created,
+// it is empty before and for jobs running on the host
+func (rc *RunContext) JobContainerID() string {
+	if c, ok := rc.JobContainer.(container.ContainerWithID); ok {
+		return c.ID()
+	}
+	return ""
+}
+
+// jobContainerStarted calls Config.OnJobContainerStart with the id of the started job container
+func (rc *RunContext) jobContainerStarted() common.Executor {
+	return func(ctx context.Context) error {
+		if id := rc.JobContainerID(); id != "" && rc.Config.OnJobContainerStart != nil {
+			rc.Config.OnJobContainerStart(ctx, rc.Run.JobID, id)
+		}
+		return nil
+	}
+}
+
+// containerWorkspace returns the path of the workspace as seen by the job container.
+// Before the job container exists it is the path the workdir gets mounted at in a
+// linux container, so `github.workspace` always matches the mount.
+func (rc *RunContext) containerWorkspace() string {
+	return rc.containerPath(rc.Config.Workdir)
+}
+
+// containerPath returns the path of a host path as seen by the job container,
+// the path of a linux container before the job container exists
+func (rc *RunContext) containerPath(hostPath string) string {
+	if rc.JobContainer == nil {
+		ext := container.LinuxContainerEnvironmentExtensions{}
+		return ext.ToContainerPath(hostPath)
 	}
+	return rc.JobContainer.ToContainerPath(hostPath)
+}
+
+// createArtifactPath creates the directory of Config.ArtifactPath if it is set
+func (rc *RunContext) createArtifactPath() error {
+	if rc.Config.ArtifactPath == "" {
+		return nil
+	}
+	if err := os.MkdirAll(rc.Config.ArtifactPath, 0o777); err != nil {
+		return fmt.Errorf("failed to create artifact path: %w", err)
+	}
+	return nil
+}
+
+// copyToJobContainer copies the files into the job container like its Copy and
+// records their paths and modes for CopiedFiles
+func (rc *RunContext) copyToJobContainer(destPath string, files ...*container.FileEntry) common.Executor {
+	copyFiles := rc.JobContainer.Copy(destPath, files...)
+	return func(ctx context.Context) error {
+		if err := copyFiles(ctx); err != nil {
+			return err
+		}
+		jobRC := rc
+		for jobRC.Parent != nil {
+			jobRC = jobRC.Parent
+		}
+	files:
+		for _, f := range files {
+			copied := container.FileEntry{Name: rc.joinActPath(destPath, f.Name), Mode: f.Mode}
+			common.Logger(ctx).Debugf("Copied %s (mode %o) into the job container", copied.Name, copied.Mode)
+			for i := range jobRC.copiedFiles {
+				if jobRC.copiedFiles[i].Name == copied.Name {
+					jobRC.copiedFiles[i] = copied
+					continue files
+				}
+			}
+			jobRC.copiedFiles = append(jobRC.copiedFiles, copied)
+		}
+		return nil
+	}
+}
+
+// CopiedFiles returns the paths and modes of the files act copied into the job
+// container so far, e.g. scripts and file commands of the steps, without their
+// content. Each path is listed once, in the order it was first copied.
+func (rc *RunContext) CopiedFiles() []container.FileEntry {
+	jobRC := rc
+	for jobRC.Parent != nil {
+		jobRC = jobRC.Parent
+	}
+	return append([]container.FileEntry{}, jobRC.copiedFiles...)
 }
 
 func (rc *RunContext) execJobContainer(cmd []string, env map[string]string, user, workdir string) common.Executor {
@@ -491,8 +727,8 @@ func (rc *RunContext) stopJobContainer() common.Executor {
 func (rc *RunContext) pullServicesImages(forcePull bool) common.Executor {
 	return func(ctx context.Context) error {
 		execs := []common.Executor{}
-		for _, c := range rc.ServiceContainers {
-			execs = append(execs, c.Pull(forcePull))
+		for i, c := range rc.ServiceContainers {
+			execs = append(execs, rc.serviceExecutor(i, "pull the image of", c.Pull(forcePull)))
 		}
 		return common.NewParallelExecutor(len(execs), execs...)(ctx)
 	}
@@ -501,17 +737,35 @@ func (rc *RunContext) pullServicesImages(forcePull bool) common.Executor {
 func (rc *RunContext) startServiceContainers(_ string) common.Executor {
 	return func(ctx context.Context) error {
 		execs := []common.Executor{}
-		for _, c := range rc.ServiceContainers {
-			execs = append(execs, common.NewPipelineExecutor(
+		for i, c := range rc.ServiceContainers {
+			execs = append(execs, rc.serviceExecutor(i, "start", common.NewPipelineExecutor(
 				c.Pull(false),
 				c.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
 				c.Start(false),
-			))
+			)))
 		}
 		return common.NewParallelExecutor(len(execs), execs...)(ctx)
 	}
 }
 
+// serviceExecutor names the service in the errors of the executor of its i-th
+// container. The job continues without a service which sets `continue-on-error`.
+func (rc *RunContext) serviceExecutor(i int, action string, executor common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		err := executor(ctx)
+		if err == nil || i >= len(rc.serviceIDs) {
+			return err
+		}
+		serviceID := rc.serviceIDs[i]
+		err = fmt.Errorf("failed to %s service %s: %w", action, serviceID, err)
+		if job := rc.Run.Job(); job != nil && job.Services[serviceID] != nil && job.Services[serviceID].ContinueOnError {
+			common.Logger(ctx).Warnf("%v, the job continues without it because of continue-on-error", err)
+			return nil
+		}
+		return err
+	}
+}
+
 func (rc *RunContext) stopServiceContainers() common.Executor {
 	return func(ctx context.Context) error {
 		execs := []common.Executor{}
@@ -524,6 +778,35 @@ func (rc *RunContext) stopServiceContainers() common.Executor {
 
 // Prepare the mounts and binds for the worker
 
+// localUsesRoot returns the directory a local `uses: ./...` reference is
+// resolved against. The workspace wins; Config.WorkflowsDir is used when the
+// reference only exists there, so centrally kept workflows can use actions and
+// reusable workflows living next to them.
+func (rc *RunContext) localUsesRoot(uses string) string {
+	if rc.Config.WorkflowsDir == "" || rc.Config.WorkflowsDir == rc.Config.Workdir {
+		return rc.Config.Workdir
+	}
+	if _, err := os.Stat(filepath.Join(rc.Config.Workdir, uses)); err == nil {
+		return rc.Config.Workdir
+	}
+	return rc.Config.WorkflowsDir
+}
+
+// localActionDir returns the host directory of a local action
+func (rc *RunContext) localActionDir(uses string) string {
+	return filepath.Join(rc.localUsesRoot(uses), uses)
+}
+
+// isInWorkdir reports whether dir is inside the workspace, and therefore
+// already available in the job container
+func (rc *RunContext) isInWorkdir(dir string) bool {
+	if rc.Config.WorkflowsDir == "" {
+		return true
+	}
+	rel, err := filepath.Rel(rc.Config.Workdir, dir)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // ActionCacheDir is for rc
 func (rc *RunContext) ActionCacheDir() string {
 	if rc.Config.ActionCacheDir != "" {
@@ -542,7 +825,8 @@ func (rc *RunContext) ActionCacheDir() string {
 	return filepath.Join(xdgCache, "act")
 }
 
-// Interpolate outputs after a job is done
+// Interpolate outputs after a job is done. Stages run in dependency order, so
+// the outputs of all needed jobs are already resolved in the needs context.
 func (rc *RunContext) interpolateOutputs() common.Executor {
 	return func(ctx context.Context) error {
 		ee := rc.NewExpressionEvaluator(ctx)
@@ -558,6 +842,13 @@ func (rc *RunContext) interpolateOutputs() common.Executor {
 
 func (rc *RunContext) startContainer() common.Executor {
 	return func(ctx context.Context) error {
+		if rc.Config.JobEnvironment != nil {
+			rc.JobContainer = rc.Config.JobEnvironment
+			rc.cleanUpJobContainer = func(ctx context.Context) error {
+				return rc.JobContainer.Remove()(ctx)
+			}
+			return nil
+		}
 		if rc.IsHostEnv(ctx) {
 			return rc.startHostEnvironment()(ctx)
 		}
@@ -613,6 +904,18 @@ func (rc *RunContext) Executor() (common.Executor, error) {
 	}
 
 	return func(ctx context.Context) error {
+		if rc.jobCancels != nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(ctx)
+			defer rc.jobCancels.add(rc.Run.JobID, cancel)()
+			if rc.jobCancelled() {
+				// a leg of a matrix waiting for max-parallel
+				common.Logger(ctx).Infof("Skipping job '%s', it was cancelled", rc.String())
+				rc.conclusion = "cancelled"
+				rc.result(combineJobResults(rc.Run.Job().Result, "cancelled"))
+				return nil
+			}
+		}
 		res, err := rc.isEnabled(ctx)
 		if err != nil {
 			return err
@@ -624,6 +927,23 @@ func (rc *RunContext) Executor() (common.Executor, error) {
 	}, nil
 }
 
+// CancelJob cancels the running job with the given id of the run of the
+// RunContext, every leg of a matrix job. The legs waiting for max-parallel
+// don't start. The job stops its steps, runs its post steps, removes its
+// container and concludes as cancelled while the other jobs continue. It
+// reports whether the job was running.
+func (rc *RunContext) CancelJob(jobID string) bool {
+	if rc.jobCancels == nil {
+		return false
+	}
+	return rc.jobCancels.cancel(jobID)
+}
+
+// jobCancelled reports whether the job of the RunContext was cancelled by CancelJob
+func (rc *RunContext) jobCancelled() bool {
+	return rc.jobCancels != nil && rc.jobCancels.isCancelled(rc.Run.JobID)
+}
+
 func (rc *RunContext) containerImage(ctx context.Context) string {
 	job := rc.Run.Job()
 
@@ -635,12 +955,42 @@ func (rc *RunContext) containerImage(ctx context.Context) string {
 	return ""
 }
 
+// runsOnImage resolves the image for the labels of `runs-on`.
+// Platforms can be configured for a set of labels joined by `+` (e.g. `self-hosted+gpu`),
+// such a platform matches if all its labels are part of `runs-on`. The matching label set
+// with the most labels takes precedence, before falling back to the first label of `runs-on`
+// with a configured platform.
 func (rc *RunContext) runsOnImage(ctx context.Context) string {
 	if rc.Run.Job().RunsOn() == nil {
 		common.Logger(ctx).Errorf("'runs-on' key not defined in %s", rc.String())
 	}
 
-	for _, platformName := range rc.runsOnPlatformNames(ctx) {
+	platformNames := rc.runsOnPlatformNames(ctx)
+	labels := make(map[string]bool, len(platformNames))
+	for _, platformName := range platformNames {
+		labels[strings.ToLower(platformName)] = true
+	}
+
+	var image, matchedKey string
+	matchedLen := 0
+	for key, platformImage := range rc.Config.Platforms {
+		if !strings.Contains(key, "+") || platformImage == "" {
+			continue
+		}
+		set := strings.Split(strings.ToLower(key), "+")
+		if !containsAllLabels(labels, set) {
+			continue
+		}
+		// prefer the most specific label set, the key only breaks ties to keep the result stable
+		if len(set) > matchedLen || (len(set) == matchedLen && key < matchedKey) {
+			image, matchedKey, matchedLen = platformImage, key, len(set)
+		}
+	}
+	if image != "" {
+		return image
+	}
+
+	for _, platformName := range platformNames {
 		image := rc.Config.Platforms[strings.ToLower(platformName)]
 		if image != "" {
 			return image
@@ -650,6 +1000,15 @@ func (rc *RunContext) runsOnImage(ctx context.Context) string {
 	return ""
 }
 
+func containsAllLabels(labels map[string]bool, set []string) bool {
+	for _, label := range set {
+		if !labels[strings.TrimSpace(label)] {
+			return false
+		}
+	}
+	return true
+}
+
 func (rc *RunContext) runsOnPlatformNames(ctx context.Context) []string {
 	job := rc.Run.Job()
 
@@ -683,6 +1042,18 @@ func (rc *RunContext) options(ctx context.Context) string {
 	return rc.Config.ContainerOptions
 }
 
+// jobContainerWorkingDir is the default working directory of the steps in the
+// job container, the workspace unless the container options set `--workdir`.
+// The working-directory of a step still takes precedence and is resolved
+// relative to it.
+func (rc *RunContext) jobContainerWorkingDir(ctx context.Context) string {
+	if workdir := container.OptionsWorkingDir(rc.options(ctx)); workdir != "" {
+		return workdir
+	}
+	ext := container.LinuxContainerEnvironmentExtensions{}
+	return ext.ToContainerPath(rc.Config.Workdir)
+}
+
 func (rc *RunContext) isEnabled(ctx context.Context) (bool, error) {
 	job := rc.Run.Job()
 	l := common.Logger(ctx)
@@ -750,7 +1121,9 @@ func trimToLen(s string, l int) string {
 	return s
 }
 
-func (rc *RunContext) getJobContext() *model.JobContext {
+// getJobContext aggregates the status of the job so far: it is cancelled once the
+// context of the job got cancelled, a failure if any prior step failed and a success otherwise
+func (rc *RunContext) getJobContext(ctx context.Context) *model.JobContext {
 	jobStatus := "success"
 	for _, stepStatus := range rc.StepResults {
 		if stepStatus.Conclusion == model.StepStatusFailure {
@@ -758,6 +1131,9 @@ func (rc *RunContext) getJobContext() *model.JobContext {
 			break
 		}
 	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		jobStatus = "cancelled"
+	}
 	return &model.JobContext{
 		Status: jobStatus,
 	}
@@ -788,7 +1164,7 @@ func (rc *RunContext) getGithubContext(ctx context.Context) *model.GithubContext
 		RunnerTrackingID: rc.Config.Env["RUNNER_TRACKING_ID"],
 		Repository:       rc.Config.Env["GITHUB_REPOSITORY"],
 		Ref:              rc.Config.Env["GITHUB_REF"],
-		Sha:              rc.Config.Env["SHA_REF"],
+		Sha:              rc.Config.Env["GITHUB_SHA"],
 		RefName:          rc.Config.Env["GITHUB_REF_NAME"],
 		RefType:          rc.Config.Env["GITHUB_REF_TYPE"],
 		BaseRef:          rc.Config.Env["GITHUB_BASE_REF"],
@@ -797,7 +1173,14 @@ func (rc *RunContext) getGithubContext(ctx context.Context) *model.GithubContext
 	}
 	if rc.JobContainer != nil {
 		ghc.EventPath = rc.JobContainer.GetActPath() + "/workflow/event.json"
-		ghc.Workspace = rc.JobContainer.ToContainerPath(rc.Config.Workdir)
+		ghc.Workspace = rc.containerWorkspace()
+	} else if ghc.Workspace == "" {
+		ghc.Workspace = rc.containerWorkspace()
+	}
+
+	if ghc.Sha == "" {
+		// SHA_REF predates GITHUB_SHA and is still honoured
+		ghc.Sha = rc.Config.Env["SHA_REF"]
 	}
 
 	if ghc.RunID == "" {
@@ -937,6 +1320,10 @@ func (rc *RunContext) withGithubEnv(ctx context.Context, github *model.GithubCon
 	env["GITHUB_SERVER_URL"] = github.ServerURL
 	env["GITHUB_API_URL"] = github.APIURL
 	env["GITHUB_GRAPHQL_URL"] = github.GraphQLURL
+	if _, ok := env["GITHUB_TOKEN"]; !ok && github.Token != "" {
+		// an explicit GITHUB_TOKEN from the workflow env takes precedence
+		env["GITHUB_TOKEN"] = github.Token
+	}
 
 	if rc.Config.ArtifactServerPath != "" {
 		setActionRuntimeVars(rc, env)
@@ -1009,6 +1396,23 @@ func (rc *RunContext) handleCredentials(ctx context.Context) (string, string, er
 	return username, password, nil
 }
 
+// registryCredentials returns the credentials to pull the image of a step container.
+// The credentials of the job container apply to images of the same registry, others
+// use the DOCKER_USERNAME and DOCKER_PASSWORD secrets. Without credentials the pull
+// falls back to the docker config of the user.
+func (rc *RunContext) registryCredentials(ctx context.Context, image string) (string, string) {
+	if job := rc.Run.Job(); job != nil {
+		if spec := job.Container(); spec != nil && spec.Credentials != nil &&
+			container.ImageRegistry(rc.ExprEval.Interpolate(ctx, spec.Image)) == container.ImageRegistry(image) {
+			// invalid credentials already failed the start of the job container
+			if username, password, err := rc.handleCredentials(ctx); err == nil {
+				return username, password
+			}
+		}
+	}
+	return rc.Config.Secrets["DOCKER_USERNAME"], rc.Config.Secrets["DOCKER_PASSWORD"]
+}
+
 func (rc *RunContext) handleServiceCredentials(ctx context.Context, creds map[string]string) (username, password string, err error) {
 	if creds == nil {
 		return
@@ -1046,6 +1450,7 @@ func (rc *RunContext) GetServiceBindsAndMounts(svcVolumes []string) ([]string, m
 	mounts := map[string]string{}
 
 	for _, v := range svcVolumes {
+		v = container.ExpandBindHostPath(v)
 		if !strings.Contains(v, ":") || filepath.IsAbs(v) {
 			// Bind anonymous volume or host file.
 			binds = append(binds, v)
@@ -1,9 +1,12 @@
 package runner
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"sort"
@@ -11,6 +14,7 @@ import (
 	"testing"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/nektos/act/pkg/container"
 	"github.com/nektos/act/pkg/exprparser"
 	"github.com/nektos/act/pkg/model"
 
@@ -303,6 +307,7 @@ func TestRunContext_GetBindsAndMounts(t *testing.T) {
 			{"BindAnonymousVolume", []string{"/volume"}, "/volume", map[string]string{}},
 			{"BindHostFile", []string{"/path/to/file/on/host:/volume"}, "/path/to/file/on/host:/volume", map[string]string{}},
 			{"MountExistingVolume", []string{"volume-id:/volume"}, "", map[string]string{"volume-id": "/volume"}},
+			{"BindExpandedHostPath", []string{"$HOME/data:/volume"}, os.Getenv("HOME") + "/data:/volume", map[string]string{}},
 		}
 
 		for _, testcase := range tests {
@@ -518,6 +523,53 @@ func TestRunContextRunsOnPlatformNames(t *testing.T) {
 	assertObject.Equal([]string{}, rc.runsOnPlatformNames(context.Background()))
 }
 
+func TestRunContextRunsOnImage(t *testing.T) {
+	platforms := map[string]string{
+		"self-hosted":            "self-hosted-image",
+		"linux":                  "linux-image",
+		"self-hosted+gpu":        "gpu-image",
+		"self-hosted+gpu+x64":    "gpu-x64-image",
+		"self-hosted+linux+arm6": "arm-image",
+	}
+
+	tables := []struct {
+		runsOn   string
+		expected string
+	}{
+		{"[self-hosted, gpu, x64]", "gpu-x64-image"},
+		{"[self-hosted, gpu]", "gpu-image"},
+		{"[gpu, Self-Hosted]", "gpu-image"},
+		{"[self-hosted, linux, x64]", "self-hosted-image"},
+		{"[linux, self-hosted]", "linux-image"},
+		{"self-hosted", "self-hosted-image"},
+		{"[gpu]", ""},
+	}
+
+	for _, table := range tables {
+		t.Run(table.runsOn, func(t *testing.T) {
+			rc := createIfTestRunContext(map[string]*model.Job{
+				"job1": createJob(t, "runs-on: "+table.runsOn, ""),
+			})
+			rc.Config.Platforms = platforms
+			assert.Equal(t, table.expected, rc.runsOnImage(context.Background()))
+		})
+	}
+}
+
+func TestRunContextContainerImageDefault(t *testing.T) {
+	ctx := context.Background()
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest
+container:
+  image: ${{ env.IMAGE || 'ubuntu:latest' }}`, ""),
+	})
+	assert.Equal(t, "ubuntu:latest", rc.platformImage(ctx))
+
+	rc.Env["IMAGE"] = "node:20"
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+	assert.Equal(t, "node:20", rc.platformImage(ctx))
+}
+
 func TestRunContextIsEnabled(t *testing.T) {
 	log.SetLevel(log.DebugLevel)
 	assertObject := assert.New(t)
@@ -633,6 +685,50 @@ if: false`, ""),
 	assertObject.False(rc.isEnabled(context.Background()))
 }
 
+func TestRunContextIsEnabledSkippedNeeds(t *testing.T) {
+	assertObject := assert.New(t)
+
+	skipped := createJob(t, `runs-on: ubuntu-latest
+outputs:
+  y: ${{ steps.s.outputs.y }}`, "skipped")
+
+	// outputs of a skipped job are empty rather than an unevaluated expression
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": skipped,
+		"job2": createJob(t, `runs-on: ubuntu-latest
+needs: [job1]
+if: always() && needs.job1.outputs.y == ''`, ""),
+	})
+	rc.Run.JobID = "job2"
+	enabled, err := rc.isEnabled(context.Background())
+	assertObject.NoError(err)
+	assertObject.True(enabled)
+
+	// property access on a missing object evaluates to empty
+	rc = createIfTestRunContext(map[string]*model.Job{
+		"job1": skipped,
+		"job2": createJob(t, `runs-on: ubuntu-latest
+needs: [job1]
+if: always() && !needs.job1.outputs.y.z && !needs.job3.outputs.y && !steps.s.outputs.y`, ""),
+	})
+	rc.Run.JobID = "job2"
+	enabled, err = rc.isEnabled(context.Background())
+	assertObject.NoError(err)
+	assertObject.True(enabled)
+
+	// a needed job that does not exist does not abort the evaluation
+	rc = createIfTestRunContext(map[string]*model.Job{
+		"job2": createJob(t, `runs-on: ubuntu-latest
+needs: [job1]
+if: needs.job1.outputs.y == 'foo'`, ""),
+	})
+	rc.Run.JobID = "job2"
+	rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+	enabled, err = rc.isEnabled(context.Background())
+	assertObject.NoError(err)
+	assertObject.False(enabled)
+}
+
 func TestRunContextGetEnv(t *testing.T) {
 	tests := []struct {
 		description string
@@ -732,3 +828,364 @@ func TestSetRuntimeVariablesWithRunID(t *testing.T) {
 	assert.True(t, ok, "scp claim exists")
 	assert.Equal(t, "Actions.Results:45:45", scp, "contains expected scp claim")
 }
+
+func TestRunContextOutputCapture(t *testing.T) {
+	capture := &bytes.Buffer{}
+	rc := &RunContext{
+		Config: &Config{
+			Secrets:       map[string]string{"TOKEN": "secret-token"},
+			OutputCapture: capture,
+		},
+		StepResults: map[string]*model.StepResult{},
+	}
+	ctx := context.Background()
+
+	// output of two steps in the order the containers produced it
+	step1 := rc.newLogWriter(ctx)
+	_, _ = step1.Write([]byte("hello world\nthe token is secret-token\n"))
+	_, _ = step1.Write([]byte("::add-mask::masked-value\n"))
+	step2 := rc.newLogWriter(ctx)
+	_, _ = step2.Write([]byte("echo masked-value\npartial "))
+	_, _ = step2.Write([]byte("line\n"))
+
+	assert.Equal(t, "hello world\n"+
+		"the token is ***\n"+
+		"echo ***\n"+
+		"partial line\n", capture.String())
+}
+
+func TestRunContextMaxLogLineBytes(t *testing.T) {
+	capture := &bytes.Buffer{}
+	rc := &RunContext{
+		Config: &Config{
+			Secrets:         map[string]string{"TOKEN": "secret-token"},
+			OutputCapture:   capture,
+			MaxLogLineBytes: 16,
+		},
+		StepResults: map[string]*model.StepResult{},
+	}
+	ctx := context.Background()
+
+	w := rc.newLogWriter(ctx)
+	_, _ = w.Write([]byte("short line\n"))
+	_, _ = w.Write([]byte(strings.Repeat("x", 1<<20) + "\n"))
+	// a secret at the cut is masked before truncating
+	_, _ = w.Write([]byte("the token: secret-token\n"))
+	// multi-byte runes are not split
+	_, _ = w.Write([]byte("0123456789abcdä€€€\n"))
+	// workflow commands still see the whole line
+	_, _ = w.Write([]byte("::add-mask::" + strings.Repeat("m", 32) + "\n"))
+
+	assert.Equal(t, "short line\n"+
+		"xxxxxxxxxxxxxxxx…[truncated]\n"+
+		"the token: ***\n"+
+		"0123456789abcdä…[truncated]\n", capture.String())
+	assert.Equal(t, []string{strings.Repeat("m", 32)}, rc.Masks)
+}
+
+func TestRunContextWorkflowsDir(t *testing.T) {
+	workdir := t.TempDir()
+	workflowsDir := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(workdir, "workspace-action"),
+		filepath.Join(workdir, "both-action"),
+		filepath.Join(workflowsDir, "both-action"),
+		filepath.Join(workflowsDir, "central-action"),
+		filepath.Join(workflowsDir, ".github", "workflows"),
+	} {
+		assert.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(workflowsDir, ".github", "workflows", "reusable.yml"), []byte("on: workflow_call"), 0o600))
+
+	rc := &RunContext{
+		Name: "TestRCName",
+		Run: &model.Run{
+			Workflow: &model.Workflow{
+				Name: "TestWorkflowName",
+			},
+		},
+		Config: &Config{
+			Workdir:      workdir,
+			WorkflowsDir: workflowsDir,
+			BindWorkdir:  true,
+		},
+		JobContainer: &containerMock{},
+	}
+
+	tests := []struct {
+		uses         string
+		root         string
+		containerDir string
+	}{
+		{"./workspace-action", workdir, "/workspace-action"},
+		{"./both-action", workdir, "/both-action"},
+		{"./central-action", workflowsDir, "/var/run/act/workflows-dir/central-action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uses, func(t *testing.T) {
+			step := &model.Step{Uses: tt.uses}
+			actionDir := rc.localActionDir(tt.uses)
+			assert.Equal(t, filepath.Join(tt.root, tt.uses), actionDir)
+			assert.Equal(t, tt.root == workdir, rc.isInWorkdir(actionDir))
+
+			_, containerActionDir := getContainerActionPaths(step, actionDir, rc)
+			if tt.root == workdir {
+				assert.Equal(t, rc.JobContainer.ToContainerPath(workdir)+tt.containerDir, containerActionDir)
+			} else {
+				assert.Equal(t, tt.containerDir, containerActionDir)
+			}
+		})
+	}
+
+	assert.Equal(t, workflowsDir, rc.localUsesRoot("./.github/workflows/reusable.yml"))
+
+	// the workspace is what gets mounted, not the workflows dir
+	binds, _ := rc.GetBindsAndMounts()
+	assert.Contains(t, binds, fmt.Sprintf("%s:%s", workdir, rc.JobContainer.ToContainerPath(workdir)))
+	for _, bind := range binds {
+		assert.NotContains(t, bind, workflowsDir)
+	}
+}
+
+func TestRunContextWorkspaceMount(t *testing.T) {
+	for _, bindWorkdir := range []bool{true, false} {
+		rc := &RunContext{
+			Name: "TestRCName",
+			Config: &Config{
+				Workdir:     "/home/user/project",
+				BindWorkdir: bindWorkdir,
+			},
+			Run: &model.Run{
+				JobID: "job1",
+				Workflow: &model.Workflow{
+					Name: "TestWorkflowName",
+					Jobs: map[string]*model.Job{
+						"job1": {},
+					},
+				},
+			},
+		}
+		workspace := rc.getGithubContext(context.Background()).Workspace
+		assert.NotEmpty(t, workspace)
+
+		binds, mounts := rc.GetBindsAndMounts()
+		if bindWorkdir {
+			found := false
+			for _, bind := range binds {
+				if strings.HasPrefix(bind, "/home/user/project:") {
+					assert.Equal(t, workspace, strings.Split(bind, ":")[1])
+					found = true
+				}
+			}
+			assert.True(t, found, "workdir is not bind mounted: %v", binds)
+		} else {
+			assert.Equal(t, workspace, mounts[rc.jobContainerName()])
+		}
+	}
+}
+
+func TestRunContextJobContainerWorkingDir(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  options:
+    runs-on: ubuntu-latest
+    container:
+      image: node:16-buster-slim
+      options: --cpus 2 --workdir /app
+    steps:
+    - run: echo
+  plain:
+    runs-on: ubuntu-latest
+    container:
+      image: node:16-buster-slim
+    steps:
+    - run: echo
+`))
+	assert.NoError(t, err)
+
+	for jobID, workdir := range map[string]string{
+		"options": "/app",
+		"plain":   "/workspace",
+	} {
+		rc := &RunContext{
+			Config: &Config{
+				Workdir: "/workspace",
+			},
+			Run: &model.Run{
+				JobID:    jobID,
+				Workflow: workflow,
+			},
+			StepResults: map[string]*model.StepResult{},
+		}
+		rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+
+		assert.Equal(t, workdir, rc.jobContainerWorkingDir(context.Background()), jobID)
+	}
+}
+
+func TestRunContextCollectedArtifacts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the step is a shell script")
+	}
+	dir := t.TempDir()
+	artifactPath := filepath.Join(dir, "artifacts")
+	rc := &RunContext{
+		Name: "TestRCName",
+		Config: &Config{
+			ArtifactPath: artifactPath,
+		},
+		Run: &model.Run{
+			JobID: "job1",
+			Workflow: &model.Workflow{
+				Name: "TestWorkflowName",
+				Jobs: map[string]*model.Job{
+					"job1": {},
+				},
+			},
+		},
+	}
+
+	artifacts, err := rc.CollectedArtifacts()
+	assert.NoError(t, err)
+	assert.Empty(t, artifacts)
+
+	binds, _ := rc.GetBindsAndMounts()
+	assert.Contains(t, binds, artifactPath+":"+artifactPath)
+	assert.Equal(t, artifactPath, rc.GetEnv()["ACT_ARTIFACT_PATH"])
+
+	// the host environment creates the artifact path like the job container does
+	ctx := context.Background()
+	rc.Config.Workdir = filepath.Join(dir, "work")
+	rc.Config.ActionCacheDir = filepath.Join(dir, "cache")
+	assert.NoError(t, rc.startHostEnvironment()(ctx))
+	defer func() {
+		assert.NoError(t, rc.JobContainer.Remove()(ctx))
+	}()
+	assert.DirExists(t, artifactPath)
+	assert.Equal(t, artifactPath, rc.GetEnv()["ACT_ARTIFACT_PATH"])
+
+	step := `mkdir -p "$ACT_ARTIFACT_PATH/dist" && echo built > "$ACT_ARTIFACT_PATH/dist/app.txt" && echo log > "$ACT_ARTIFACT_PATH/build.log"`
+	stepEnv := mergeMaps(rc.GetEnv(), map[string]string{"PATH": os.Getenv("PATH")})
+	assert.NoError(t, rc.JobContainer.Exec([]string{"sh", "-c", step}, stepEnv, "", "")(ctx))
+
+	artifacts, err = rc.CollectedArtifacts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build.log", "dist/app.txt"}, artifacts)
+}
+
+func TestRunContextInterpolateOutputsNeedsChain(t *testing.T) {
+	planner, err := model.NewSingleWorkflowPlanner("chain.yml", strings.NewReader(`
+name: chain
+on: push
+jobs:
+  c:
+    runs-on: ubuntu-latest
+    needs: [b]
+    outputs:
+      z: ${{ needs.b.outputs.x }}
+  b:
+    runs-on: ubuntu-latest
+    needs: [a]
+    outputs:
+      x: ${{ needs.a.outputs.y }}-b
+  a:
+    runs-on: ubuntu-latest
+    outputs:
+      y: ${{ 'from-a' }}
+`))
+	assert.NoError(t, err)
+	plan, err := planner.PlanJob("c")
+	assert.NoError(t, err)
+
+	order := []string{}
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			order = append(order, run.JobID)
+			rc := &RunContext{
+				Config: &Config{Workdir: "."},
+				Env:    map[string]string{},
+				Run:    run,
+			}
+			assert.NoError(t, rc.interpolateOutputs()(context.Background()))
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, order)
+
+	jobs := plan.Stages[0].Runs[0].Workflow.Jobs
+	assert.Equal(t, "from-a-b", jobs["b"].Outputs["x"])
+	assert.Equal(t, "from-a-b", jobs["c"].Outputs["z"])
+}
+
+func TestRunContextJobContainerStarted(t *testing.T) {
+	ctx := context.Background()
+	started := map[string]string{}
+	rc := &RunContext{
+		Config: &Config{
+			OnJobContainerStart: func(_ context.Context, jobID string, containerID string) {
+				started[jobID] = containerID
+			},
+		},
+		Run: &model.Run{JobID: "build"},
+	}
+	assert.Equal(t, "", rc.JobContainerID())
+
+	cm := &containerMock{}
+	cm.On("ID").Return("0123abcd")
+	rc.JobContainer = cm
+	assert.NoError(t, rc.jobContainerStarted()(ctx))
+	assert.Equal(t, "0123abcd", rc.JobContainerID())
+	assert.Equal(t, map[string]string{"build": "0123abcd"}, started)
+
+	// not called without a container, e.g. in a dry run
+	cm = &containerMock{}
+	cm.On("ID").Return("")
+	rc.JobContainer = cm
+	started = map[string]string{}
+	assert.NoError(t, rc.jobContainerStarted()(ctx))
+	assert.Empty(t, started)
+}
+
+func TestRunContextStartServiceContainersError(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    services:
+      db:
+        image: "invalid image"
+      cache:
+        image: "invalid image"
+        continue-on-error: true
+    steps:
+    - run: echo
+`))
+	assert.NoError(t, err)
+
+	newService := func() *containerMock {
+		cm := &containerMock{}
+		cm.On("Pull", false).Return(func(context.Context) error {
+			return errors.New("invalid reference format")
+		})
+		cm.On("Create", []string(nil), []string(nil)).Return(func(context.Context) error { return nil })
+		cm.On("Start", false).Return(func(context.Context) error { return nil })
+		return cm
+	}
+	rc := &RunContext{
+		Config: &Config{},
+		Run: &model.Run{
+			JobID:    "build",
+			Workflow: workflow,
+		},
+		ServiceContainers: []container.ExecutionsEnvironment{newService()},
+		serviceIDs:        []string{"db"},
+	}
+	err = rc.startServiceContainers("")(context.Background())
+	assert.EqualError(t, err, "failed to start service db: invalid reference format")
+
+	// an optional service is skipped
+	rc.ServiceContainers = []container.ExecutionsEnvironment{newService()}
+	rc.serviceIDs = []string{"cache"}
+	assert.NoError(t, rc.startServiceContainers("")(context.Background()))
+}
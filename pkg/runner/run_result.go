@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// RunResult is the outcome of an executed plan
+type RunResult struct {
+	Jobs       map[string]string // conclusion of every job of the plan by its id, a failed leg of a matrix fails the job, a cancelled one cancels it
+	Legs       map[string]string // conclusion of every leg of the matrix jobs by its name, e.g. "build-2"
+	Conclusion string            // success, failure or cancelled
+}
+
+// NewRunResult aggregates the results of the jobs of an executed plan.
+// The run fails if any job failed, unless the job has continue-on-error.
+// Otherwise it is cancelled if a job or the run itself (ctx) was cancelled.
+// Jobs that didn't run are skipped and don't affect the conclusion.
+func NewRunResult(ctx context.Context, plan *model.Plan) *RunResult {
+	return newRunResult(ctx, plan, nil)
+}
+
+func newRunResult(ctx context.Context, plan *model.Plan, legs *legConclusions) *RunResult {
+	cancelled := errors.Is(ctx.Err(), context.Canceled)
+	result := &RunResult{
+		Jobs:       map[string]string{},
+		Legs:       legs.get(),
+		Conclusion: "success",
+	}
+
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			conclusion := run.Job().Result
+			if conclusion == "" {
+				conclusion = "skipped"
+				if cancelled {
+					conclusion = "cancelled"
+				}
+			}
+			result.Jobs[run.JobID] = conclusion
+
+			switch {
+			case conclusion == "failure" && !run.Job().FailureAllowed:
+				result.Conclusion = "failure"
+			case conclusion == "cancelled" && result.Conclusion == "success":
+				result.Conclusion = "cancelled"
+			}
+		}
+	}
+	if cancelled && result.Conclusion == "success" {
+		result.Conclusion = "cancelled"
+	}
+
+	return result
+}
+
+// legConclusions collects the conclusions of the legs of the matrix jobs of a run
+type legConclusions struct {
+	mu     sync.Mutex
+	byName map[string]string
+}
+
+func (l *legConclusions) set(name string, conclusion string) {
+	if conclusion == "" {
+		conclusion = "skipped"
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.byName[name] = conclusion
+}
+
+func (l *legConclusions) get() map[string]string {
+	legs := map[string]string{}
+	if l == nil {
+		return legs
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for name, conclusion := range l.byName {
+		legs[name] = conclusion
+	}
+	return legs
+}
+
+// ExitCode maps the conclusion of the run to a process exit code
+func (r *RunResult) ExitCode() int {
+	if r.Conclusion == "success" {
+		return 0
+	}
+	return 1
+}
+
+// RunFailedError is returned by the plan executor if a job of the run failed
+type RunFailedError struct {
+	Job    string
+	Result *RunResult
+}
+
+func (e *RunFailedError) Error() string {
+	return fmt.Sprintf("Job '%s' failed", e.Job)
+}
@@ -0,0 +1,192 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+func newResultPlan(results map[string]string) *model.Plan {
+	workflow := &model.Workflow{Jobs: map[string]*model.Job{}}
+	stage := &model.Stage{}
+	for id, result := range results {
+		workflow.Jobs[id] = &model.Job{Result: result}
+		stage.Runs = append(stage.Runs, &model.Run{JobID: id, Workflow: workflow})
+	}
+	return &model.Plan{Stages: []*model.Stage{stage}}
+}
+
+func TestNewRunResult(t *testing.T) {
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	table := []struct {
+		name       string
+		ctx        context.Context
+		results    map[string]string
+		jobs       map[string]string
+		conclusion string
+	}{
+		{
+			name:       "success",
+			ctx:        context.Background(),
+			results:    map[string]string{"build": "success", "deploy": ""},
+			jobs:       map[string]string{"build": "success", "deploy": "skipped"},
+			conclusion: "success",
+		},
+		{
+			name:       "failure",
+			ctx:        context.Background(),
+			results:    map[string]string{"build": "success", "test": "failure"},
+			jobs:       map[string]string{"build": "success", "test": "failure"},
+			conclusion: "failure",
+		},
+		{
+			name:       "cancelled",
+			ctx:        cancelledCtx,
+			results:    map[string]string{"build": "success", "test": ""},
+			jobs:       map[string]string{"build": "success", "test": "cancelled"},
+			conclusion: "cancelled",
+		},
+		{
+			name:       "failureWinsOverCancelled",
+			ctx:        cancelledCtx,
+			results:    map[string]string{"build": "failure", "test": ""},
+			jobs:       map[string]string{"build": "failure", "test": "cancelled"},
+			conclusion: "failure",
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewRunResult(tt.ctx, newResultPlan(tt.results))
+			assert.Equal(t, tt.jobs, result.Jobs)
+			assert.Equal(t, tt.conclusion, result.Conclusion)
+			if tt.conclusion == "success" {
+				assert.Equal(t, 0, result.ExitCode())
+			} else {
+				assert.Equal(t, 1, result.ExitCode())
+			}
+		})
+	}
+}
+
+func TestHandleFailureRunResult(t *testing.T) {
+	plan := newResultPlan(map[string]string{"test": "failure"})
+
+	err := handleFailure(plan, nil)(context.Background())
+	assert.EqualError(t, err, "Job 'test' failed")
+
+	var runErr *RunFailedError
+	assert.True(t, errors.As(err, &runErr))
+	assert.Equal(t, "failure", runErr.Result.Conclusion)
+	assert.Equal(t, 1, runErr.Result.ExitCode())
+
+	// a job with continue-on-error keeps its failure, but doesn't fail the run
+	plan = newResultPlan(map[string]string{"experimental": "failure"})
+	plan.Stages[0].Runs[0].Job().FailureAllowed = true
+	assert.NoError(t, handleFailure(plan, nil)(context.Background()))
+	result := NewRunResult(context.Background(), plan)
+	assert.Equal(t, "success", result.Conclusion)
+	assert.Equal(t, map[string]string{"experimental": "failure"}, result.Jobs)
+}
+
+// legEnvironment is a RecordingEnvironment whose commands fail for the matrix leg FAIL_LEG
+type legEnvironment struct {
+	*container.RecordingEnvironment
+}
+
+func (e *legEnvironment) Exec(command []string, env map[string]string, user, workdir string) common.Executor {
+	record := e.RecordingEnvironment.Exec(command, env, user, workdir)
+	return func(ctx context.Context) error {
+		if err := record(ctx); err != nil {
+			return err
+		}
+		if env["LEG"] == env["FAIL_LEG"] {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+}
+
+func TestRunnerOnRunResult(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: result
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    strategy:
+      fail-fast: false
+      matrix:
+        leg: [1, 2, 3]
+    steps:
+    - run: echo
+      env:
+        LEG: ${{ matrix.leg }}
+`))
+	assert.NoError(t, err)
+
+	run := func(ctx context.Context, failLeg string) (*RunResult, error) {
+		for _, job := range workflow.Jobs {
+			job.Result = ""
+			job.FailureAllowed = false
+		}
+		planner, err := model.NewWorkflowPlannerFromWorkflow(workflow)
+		assert.NoError(t, err)
+		plan, err := planner.PlanEvent("push")
+		assert.NoError(t, err)
+
+		var result *RunResult
+		r, err := New(&Config{
+			Workdir:        "/work",
+			ActionCacheDir: t.TempDir(),
+			EventName:      "push",
+			Platforms: map[string]string{
+				"ubuntu-latest": "node:16-buster-slim",
+			},
+			GitHubInstance: "github.com",
+			Env:            map[string]string{"FAIL_LEG": failLeg},
+			JobEnvironment: &legEnvironment{&container.RecordingEnvironment{}},
+			OnRunResult: func(_ context.Context, r *RunResult) {
+				result = r
+			},
+		})
+		assert.NoError(t, err)
+		return result, r.NewPlanExecutor(plan)(ctx)
+	}
+
+	result, err := run(context.Background(), "none")
+	assert.NoError(t, err)
+	assert.Equal(t, &RunResult{
+		Jobs:       map[string]string{"build": "success"},
+		Legs:       map[string]string{"build-1": "success", "build-2": "success", "build-3": "success"},
+		Conclusion: "success",
+	}, result)
+
+	result, err = run(context.Background(), "2")
+	assert.Error(t, err)
+	assert.Equal(t, &RunResult{
+		Jobs:       map[string]string{"build": "failure"},
+		Legs:       map[string]string{"build-1": "success", "build-2": "failure", "build-3": "success"},
+		Conclusion: "failure",
+	}, result)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, _ = run(ctx, "none")
+	if assert.NotNil(t, result) {
+		assert.Equal(t, "cancelled", result.Conclusion)
+	}
+
+	// a failed leg isn't hidden by a later cancelled one
+	assert.Equal(t, "failure", combineJobResults("failure", "cancelled"))
+	assert.Equal(t, "cancelled", combineJobResults("success", "cancelled"))
+}
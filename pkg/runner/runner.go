@@ -2,13 +2,18 @@ package runner
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
+	"strings"
 
 	docker_container "github.com/docker/docker/api/types/container"
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
 	"github.com/nektos/act/pkg/model"
 	log "github.com/sirupsen/logrus"
 )
@@ -18,13 +23,31 @@ type Runner interface {
 	NewPlanExecutor(plan *model.Plan) common.Executor
 }
 
+// JobCanceller is implemented by the Runner returned by New, it cancels a single running job
+type JobCanceller interface {
+	CancelJob(jobID string) bool
+}
+
+// MatrixRunner is implemented by the Runner returned by New, it runs a single matrix leg
+type MatrixRunner interface {
+	NewMatrixExecutor(run *model.Run, matrix map[string]interface{}) common.Executor
+}
+
+// JobContainerHook is called with the id of a job and the id of its container
+type JobContainerHook func(ctx context.Context, jobID string, containerID string)
+
+// RunResultHook is called with the result of an executed plan
+type RunResultHook func(ctx context.Context, result *RunResult)
+
 // Config contains the config for a new runner
 type Config struct {
 	Actor                              string                       // the user that triggered the event
 	Workdir                            string                       // path to working directory
+	WorkflowsDir                       string                       // path to the repository holding workflows and local actions, defaults to Workdir
 	ActionCacheDir                     string                       // path used for caching action contents
 	ActionOfflineMode                  bool                         // when offline, use caching action contents
 	BindWorkdir                        bool                         // bind the workdir to the job container
+	BindWorkdirSet                     bool                         // BindWorkdir is set explicitly (e.g. --bind=false), MergeConfig keeps it over the config file
 	EventName                          string                       // name of event to run
 	EventPath                          string                       // path to JSON file to use for event.json in containers
 	DefaultBranch                      string                       // name of the main branch for this repository
@@ -32,26 +55,43 @@ type Config struct {
 	ForcePull                          bool                         // force pulling of the image, even if already present
 	ForceRebuild                       bool                         // force rebuilding local docker image action
 	LogOutput                          bool                         // log the output from docker run
+	ShowTimestamps                     bool                         // prefix each output line of the steps with the time it was logged
+	MaxLogLineBytes                    int                          // truncate longer lines of the output of steps, 0 keeps them whole
+	OutputCapture                      io.Writer                    // receives a copy of the output of all steps with masked secrets, e.g. for golden file tests
+	StepOutputTail                     int                          // number of the last output lines of a failed step kept in its StepResult, 0 keeps none
+	StrictWorkflowCommands             bool                         // fail the step on unknown or malformed workflow commands instead of ignoring them
 	JSONLogger                         bool                         // use json or text logger
 	LogPrefixJobID                     bool                         // switches from the full job name to the job id
+	JobLogLevels                       map[string]log.Level         // log level of a job by its id, other jobs log at the global level
 	Env                                map[string]string            // env for containers
+	EnvFiles                           []string                     // files in the format of GITHUB_ENV merged in order below Env, later files win
 	Inputs                             map[string]string            // manually passed action inputs
 	Secrets                            map[string]string            // list of secrets
+	SecretResolver                     SecretResolver               // resolves secrets missing from Secrets on demand, resolved values are masked
+	SecretEnvPrefix                    string                       // env vars of act with this prefix are secrets named without it (e.g. ACT_SECRET_FOO is FOO), empty disables it
 	Vars                               map[string]string            // list of vars
 	Token                              string                       // GitHub token
 	InsecureSecrets                    bool                         // switch hiding output when printing to terminal
+	MaskedEnv                          []string                     // names of env vars (of Env or the host) whose values are masked like secrets
 	Platforms                          map[string]string            // list of platforms
+	ShellInterpreters                  map[string]string            // interpreter path of a builtin shell (e.g. bash=/usr/local/bin/bash), for images where it is not on the PATH
+	NonStrictBash                      bool                         // run `shell: bash` steps without `-e -o pipefail`, for legacy scripts
 	Privileged                         bool                         // use privileged mode
 	UsernsMode                         string                       // user namespace to use
 	ContainerArchitecture              string                       // Desired OS/architecture platform for running containers
 	ContainerDaemonSocket              string                       // Path to Docker daemon socket
 	ContainerOptions                   string                       // Options for the job container
+	ContainerNamePrefix                string                       // prefix of the names of containers, volumes and networks, defaults to "act"
+	UniqueContainerNames               bool                         // add a random id of the run to container names so concurrent runs on one host don't collide, ignored with ReuseContainers
+	ContainerBuildArgs                 map[string]string            // build args passed when building the image of a Dockerfile action
+	DeprecatedActionRuntimes           []model.ActionRunsUsing      // runtimes of actions which log a warning, defaults to node12 and node16, empty disables the warning
 	UseGitIgnore                       bool                         // controls if paths in .gitignore should not be copied into container, default true
 	GitHubInstance                     string                       // GitHub instance to use, default "github.com"
 	ContainerCapAdd                    []string                     // list of kernel capabilities to add to the containers
 	ContainerCapDrop                   []string                     // list of kernel capabilities to remove from the containers
 	AutoRemove                         bool                         // controls if the container is automatically removed upon workflow completion
 	ArtifactServerPath                 string                       // the path where the artifact server stores uploads
+	ArtifactPath                       string                       // host directory mounted into the job container for files steps leave behind, exposed to steps as ACT_ARTIFACT_PATH
 	ArtifactServerAddr                 string                       // the address the artifact server binds to
 	ArtifactServerPort                 string                       // the port the artifact server binds to
 	NoSkipCheckout                     bool                         // do not skip actions/checkout
@@ -61,6 +101,19 @@ type Config struct {
 	Matrix                             map[string]map[string]bool   // Matrix config to run
 	ContainerNetworkMode               docker_container.NetworkMode // the network mode of job containers (the value of --network)
 	ActionCache                        ActionCache                  // Use a custom ActionCache Implementation
+	EnvFileSizeLimit                   int                          // maximum total size in bytes of the variables a step may set via GITHUB_ENV, defaults to 1 MiB
+	DefaultMaxParallel                 int                          // number of matrix jobs run in parallel when the strategy doesn't set max-parallel, defaults to 4
+	RandomizeFileCommands              bool                         // use random per-step file names for GITHUB_OUTPUT, GITHUB_ENV and the other file commands
+	RegistryMirrors                    map[string]string            // rewrite image references matching a repository prefix (e.g. docker.io/library) to a mirror
+	OnJobContainerStart                JobContainerHook             // called with the id of the job container once it is started, e.g. to docker exec into it
+	OnRunResult                        RunResultHook                // called with the result of every plan executed by NewPlanExecutor, whatever its conclusion
+	StepFilter                         func(*model.Step, int) bool  // run only the steps (and their index within the job) for which the filter returns true, others are skipped
+	EnvironmentSecrets                 map[string]map[string]string // secrets of a deployment environment by its name, they override Secrets for jobs using the environment
+
+	// JobEnvironment runs the steps of all jobs instead of docker or the host,
+	// e.g. a container.RecordingEnvironment to test the control flow of workflows.
+	// Its Remove is called where the container of a job would be removed.
+	JobEnvironment container.ExecutionsEnvironment
 }
 
 type caller struct {
@@ -71,18 +124,70 @@ type runnerImpl struct {
 	config    *Config
 	eventJSON string
 	caller    *caller // the job calling this runner (caller of a reusable workflow)
+	runID     string  // suffix of container names with Config.UniqueContainerNames
+	cancels   *jobCancels
+}
+
+// NewWorkflowExecutor returns an executor running an already parsed workflow,
+// e.g. one read from a string with model.ReadWorkflow, for Config.EventName.
+// All jobs of the workflow run if no event is set.
+func NewWorkflowExecutor(config *Config, workflow *model.Workflow) (common.Executor, error) {
+	planner, err := model.NewWorkflowPlannerFromWorkflow(workflow)
+	if err != nil {
+		return nil, err
+	}
+	var plan *model.Plan
+	if config.EventName != "" {
+		plan, err = planner.PlanEvent(config.EventName)
+	} else {
+		plan, err = planner.PlanAll()
+	}
+	if err != nil {
+		return nil, err
+	}
+	runner, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	return runner.NewPlanExecutor(plan), nil
 }
 
 // New Creates a new Runner
 func New(runnerConfig *Config) (Runner, error) {
+	// configure merges the env files and secrets without changing the config of the caller
+	config := *runnerConfig
 	runner := &runnerImpl{
-		config: runnerConfig,
+		config:  &config,
+		cancels: newJobCancels(),
+	}
+	if runnerConfig.UniqueContainerNames && !runnerConfig.ReuseContainers {
+		randBytes := make([]byte, 4)
+		if _, err := rand.Read(randBytes); err != nil {
+			return nil, err
+		}
+		runner.runID = hex.EncodeToString(randBytes)
 	}
 
 	return runner.configure()
 }
 
 func (runner *runnerImpl) configure() (Runner, error) {
+	if len(runner.config.EnvFiles) > 0 {
+		env, err := container.LoadEnvFiles(runner.config.EnvFiles...)
+		if err != nil {
+			return nil, err
+		}
+		// env passed explicitly wins over the env files
+		runner.config.Env = mergeMaps(env, runner.config.Env)
+	}
+	if runner.config.SecretEnvPrefix != "" {
+		// secrets passed explicitly win over the ones of the environment
+		runner.config.Secrets = mergeMaps(secretsFromEnv(runner.config.SecretEnvPrefix, os.Environ()), runner.config.Secrets)
+		if runner.config.Token == "" {
+			runner.config.Token = runner.config.Secrets["GITHUB_TOKEN"]
+		}
+	}
+
 	runner.eventJSON = "{}"
 	if runner.config.EventPath != "" {
 		log.Debugf("Reading event.json from %s", runner.config.EventPath)
@@ -104,12 +209,28 @@ func (runner *runnerImpl) configure() (Runner, error) {
 	return runner, nil
 }
 
+// secretsFromEnv returns the variables of environ (KEY=value) starting with
+// prefix as secrets, named without the prefix
+func secretsFromEnv(prefix string, environ []string) map[string]string {
+	secrets := map[string]string{}
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if name, ok := strings.CutPrefix(name, prefix); ok && name != "" {
+			secrets[name] = value
+		}
+	}
+	return secrets
+}
+
 // NewPlanExecutor ...
 func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 	maxJobNameLen := 0
 
 	stagePipeline := make([]common.Executor, 0)
 	log.Debugf("Plan Stages: %v", plan.Stages)
+	activityType := runner.eventActivityType()
+	runner.lintRunsOn(plan)
+	legs := &legConclusions{byName: map[string]string{}}
 
 	for i := range plan.Stages {
 		stage := plan.Stages[i]
@@ -117,6 +238,10 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 			pipeline := make([]common.Executor, 0)
 			for _, run := range stage.Runs {
 				log.Debugf("Stages Runs: %v", stage.Runs)
+				if runner.caller == nil && !runner.shouldRunForEvent(run.Workflow, activityType) {
+					log.Debugf("Skipping job '%s', workflow '%s' does not run for '%s' of type '%s'", run.JobID, run.Workflow.Name, runner.config.EventName, activityType)
+					continue
+				}
 				stageExecutor := make([]common.Executor, 0)
 				job := run.Job()
 				log.Debugf("Job.Name: %v", job.Name)
@@ -138,6 +263,7 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 				log.Debugf("Job.Outputs: %v", job.Outputs)
 				log.Debugf("Job.Uses: %v", job.Uses)
 				log.Debugf("Job.With: %v", job.With)
+				log.Debugf("Job.RawEnvironment: %v", job.RawEnvironment)
 				// log.Debugf("Job.RawSecrets: %v", job.RawSecrets)
 				log.Debugf("Job.Result: %v", job.Result)
 
@@ -148,6 +274,8 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 					log.Debugf("Job.Strategy.MaxParallelString: %v", job.Strategy.MaxParallelString)
 					log.Debugf("Job.Strategy.RawMatrix: %v", job.Strategy.RawMatrix)
 
+					job.Strategy.DefaultMaxParallel = runner.config.DefaultMaxParallel
+
 					strategyRc := runner.newRunContext(ctx, run, nil)
 					if err := strategyRc.NewExpressionEvaluator(ctx).EvaluateYamlNode(ctx, &job.Strategy.RawMatrix); err != nil {
 						log.Errorf("Error while evaluating matrix: %v", err)
@@ -164,7 +292,7 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 				}
 				log.Debugf("Final matrix after applying user inclusions '%v'", matrixes)
 
-				maxParallel := 4
+				maxParallel := model.Strategy{DefaultMaxParallel: runner.config.DefaultMaxParallel}.GetMaxParallel()
 				if job.Strategy != nil {
 					maxParallel = job.Strategy.MaxParallel
 				}
@@ -191,10 +319,18 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 							return err
 						}
 
-						return executor(common.WithJobErrorContainer(WithJobLogger(ctx, rc.Run.JobID, jobName, rc.Config, &rc.Masks, matrix)))
+						err = executor(common.WithJobErrorContainer(WithJobLogger(ctx, rc.Run.JobID, jobName, rc.Config, &rc.Masks, matrix)))
+						if len(matrixes) > 1 {
+							legs.set(rc.Name, rc.conclusion)
+						}
+						return err
 					})
 				}
-				pipeline = append(pipeline, common.NewParallelExecutor(maxParallel, stageExecutor...))
+				jobID := run.JobID
+				pipeline = append(pipeline, common.NewParallelExecutor(maxParallel, stageExecutor...).Finally(func(ctx context.Context) error {
+					runner.cancels.done(jobID)
+					return nil
+				}))
 			}
 			ncpu := runtime.NumCPU()
 			if 1 > ncpu {
@@ -205,15 +341,94 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 		})
 	}
 
-	return common.NewPipelineExecutor(stagePipeline...).Then(handleFailure(plan))
+	return common.NewPipelineExecutor(stagePipeline...).Then(handleFailure(plan, legs)).Finally(func(ctx context.Context) error {
+		if runner.config.OnRunResult != nil {
+			runner.config.OnRunResult(ctx, newRunResult(ctx, plan, legs))
+		}
+		return nil
+	})
 }
 
-func handleFailure(plan *model.Plan) common.Executor {
+// NewMatrixExecutor runs the job of run once with the given matrix combination,
+// without expanding the matrix of the job. It allows to retry a single failed leg,
+// the executor returns the error of the leg if it failed.
+func (runner *runnerImpl) NewMatrixExecutor(run *model.Run, matrix map[string]interface{}) common.Executor {
+	return func(ctx context.Context) error {
+		rc := runner.newRunContext(ctx, run, matrix)
+		rc.JobName = rc.Name
+		executor, err := rc.Executor()
+		if err != nil {
+			return err
+		}
+		ctx = common.WithJobErrorContainer(WithJobLogger(ctx, rc.Run.JobID, rc.String(), rc.Config, &rc.Masks, matrix))
+		defer runner.cancels.done(run.JobID)
+		if err := executor(ctx); err != nil {
+			return err
+		}
+		return common.JobError(ctx)
+	}
+}
+
+// CancelJob cancels the running job with the given id, see RunContext.CancelJob
+func (runner *runnerImpl) CancelJob(jobID string) bool {
+	if runner.cancels == nil {
+		return false
+	}
+	return runner.cancels.cancel(jobID)
+}
+
+// lintRunsOn warns about runs-on labels of the planned workflows which are
+// neither a default label nor configured in Config.Platforms
+func (runner *runnerImpl) lintRunsOn(plan *model.Plan) {
+	labels := make([]string, 0, len(runner.config.Platforms))
+	for label := range runner.config.Platforms {
+		labels = append(labels, label)
+	}
+	linted := map[*model.Workflow]bool{}
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			if linted[run.Workflow] {
+				continue
+			}
+			linted[run.Workflow] = true
+			for _, problem := range run.Workflow.LintRunsOn(labels) {
+				log.Warnf("workflow '%s': %v", run.Workflow.Name, problem)
+			}
+		}
+	}
+}
+
+// eventActivityType returns the activity type of the event, the `action` of its payload
+func (runner *runnerImpl) eventActivityType() string {
+	var event struct {
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal([]byte(runner.eventJSON), &event); err != nil {
+		return ""
+	}
+	return event.Action
+}
+
+// shouldRunForEvent applies the activity type filters of the workflow, a
+// workflow planned by job or without listening to the event always runs
+func (runner *runnerImpl) shouldRunForEvent(w *model.Workflow, activityType string) bool {
+	for _, event := range w.On() {
+		if event == runner.config.EventName {
+			return w.ShouldRunForEvent(event, activityType)
+		}
+	}
+	return true
+}
+
+func handleFailure(plan *model.Plan, legs *legConclusions) common.Executor {
 	return func(ctx context.Context) error {
 		for _, stage := range plan.Stages {
 			for _, run := range stage.Runs {
-				if run.Job().Result == "failure" {
-					return fmt.Errorf("Job '%s' failed", run.String())
+				if run.Job().Result == "failure" && !run.Job().FailureAllowed {
+					return &RunFailedError{
+						Job:    run.String(),
+						Result: newRunResult(ctx, plan, legs),
+					}
 				}
 			}
 		}
@@ -248,6 +463,23 @@ func (runner *runnerImpl) newRunContext(ctx context.Context, run *model.Run, mat
 		StepResults: make(map[string]*model.StepResult),
 		Matrix:      matrix,
 		caller:      runner.caller,
+		runID:       runner.runID,
+		jobCancels:  runner.cancels,
+	}
+	if runner.config.Token != "" {
+		rc.AddMask(runner.config.Token)
+	}
+	for _, name := range runner.config.MaskedEnv {
+		if v := runner.config.Env[name]; v != "" {
+			rc.AddMask(v)
+		}
+		if v := os.Getenv(name); v != "" {
+			rc.AddMask(v)
+		}
+	}
+	rc.deploymentEnvironmentName = rc.resolveDeploymentEnvironmentName(ctx)
+	for _, v := range runner.config.EnvironmentSecrets[rc.deploymentEnvironmentName] {
+		rc.AddMask(v)
 	}
 	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
 	rc.Name = rc.ExprEval.Interpolate(ctx, run.String())
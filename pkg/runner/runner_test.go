@@ -3,6 +3,7 @@ package runner
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,13 +12,16 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/joho/godotenv"
 	log "github.com/sirupsen/logrus"
 	assert "github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
 
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
 	"github.com/nektos/act/pkg/model"
 )
 
@@ -558,6 +562,198 @@ func TestMaskValues(t *testing.T) {
 	assertNoSecret(output, "YWJjCg==")
 }
 
+func TestNewRunContextToken(t *testing.T) {
+	runner := &runnerImpl{
+		config: &Config{Token: "ghs_token_value"},
+	}
+	run := &model.Run{
+		JobID: "job1",
+		Workflow: &model.Workflow{
+			Name: "test",
+			Jobs: map[string]*model.Job{
+				"job1": {},
+			},
+		},
+	}
+	ctx := context.Background()
+	rc := runner.newRunContext(ctx, run, nil)
+
+	assert.Equal(t, "ghs_token_value", rc.ExprEval.Interpolate(ctx, "${{ github.token }}"))
+
+	env := rc.withGithubEnv(ctx, rc.getGithubContext(ctx), map[string]string{})
+	assert.Equal(t, "ghs_token_value", env["GITHUB_TOKEN"])
+
+	env = rc.withGithubEnv(ctx, rc.getGithubContext(ctx), map[string]string{"GITHUB_TOKEN": "from-env"})
+	assert.Equal(t, "from-env", env["GITHUB_TOKEN"])
+
+	logger := &maskJobLoggerFactory{}
+	ctx = WithJobLogger(WithJobLoggerFactory(ctx, logger), run.JobID, "job1", rc.Config, &rc.Masks, nil)
+	common.Logger(ctx).Infof("token is %s", "ghs_token_value")
+	assert.NotContains(t, logger.Output.String(), "ghs_token_value")
+	assert.Contains(t, logger.Output.String(), "token is ***")
+}
+
+func TestNewRunContextMaskedEnv(t *testing.T) {
+	t.Setenv("CORPORATE_TOKEN", "host-token-value")
+	capture := &bytes.Buffer{}
+	runner := &runnerImpl{
+		config: &Config{
+			Env:           map[string]string{"PASSED_TOKEN": "passed-token-value", "PLAIN": "plain-value"},
+			MaskedEnv:     []string{"CORPORATE_TOKEN", "PASSED_TOKEN", "UNSET_TOKEN"},
+			OutputCapture: capture,
+		},
+	}
+	run := &model.Run{
+		JobID: "job1",
+		Workflow: &model.Workflow{
+			Name: "test",
+			Jobs: map[string]*model.Job{
+				"job1": {},
+			},
+		},
+	}
+	ctx := context.Background()
+	rc := runner.newRunContext(ctx, run, nil)
+
+	_, _ = rc.newLogWriter(ctx).Write([]byte("host-token-value passed-token-value plain-value\n"))
+	assert.Equal(t, "*** *** plain-value\n", capture.String())
+}
+
+type infoJobLoggerFactory struct {
+	Output bytes.Buffer
+}
+
+func (f *infoJobLoggerFactory) WithJobLogger() *log.Logger {
+	logger := log.New()
+	logger.SetOutput(&f.Output)
+	logger.SetLevel(log.InfoLevel)
+	return logger
+}
+
+func TestJobLogLevels(t *testing.T) {
+	config := &Config{
+		JobLogLevels: map[string]log.Level{"verbose": log.DebugLevel},
+	}
+	for jobID, logged := range map[string]bool{
+		"verbose": true,
+		"quiet":   false,
+	} {
+		factory := &infoJobLoggerFactory{}
+		ctx := WithJobLogger(WithJobLoggerFactory(context.Background(), factory), jobID, jobID, config, &[]string{}, nil)
+		common.Logger(ctx).Debugf("debug line of %s", jobID)
+		common.Logger(ctx).Infof("info line of %s", jobID)
+
+		assert.Equal(t, logged, strings.Contains(factory.Output.String(), "debug line of "+jobID), jobID)
+		assert.Contains(t, factory.Output.String(), "info line of "+jobID)
+	}
+}
+
+func TestNewRunContextDeploymentEnvironment(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+jobs:
+  deploy:
+    environment:
+      name: production
+      url: https://${{ vars.HOST }}/app
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+`))
+	assert.NoError(t, err)
+
+	runner := &runnerImpl{
+		config: &Config{
+			Secrets: map[string]string{"API_KEY": "repo-key", "OTHER": "other"},
+			Vars:    map[string]string{"HOST": "example.com"},
+			EnvironmentSecrets: map[string]map[string]string{
+				"production": {"API_KEY": "production-key"},
+			},
+		},
+	}
+	ctx := context.Background()
+	rc := runner.newRunContext(ctx, &model.Run{JobID: "deploy", Workflow: workflow}, nil)
+
+	assert.Equal(t, &model.JobEnvironment{Name: "production", URL: "https://example.com/app"}, rc.DeploymentEnvironment(ctx))
+	assert.Equal(t, "production-key", rc.ExprEval.Interpolate(ctx, "${{ secrets.API_KEY }}"))
+	assert.Equal(t, "other", rc.ExprEval.Interpolate(ctx, "${{ secrets.OTHER }}"))
+	assert.Contains(t, rc.Masks, "production-key")
+	assert.Equal(t, "repo-key", runner.config.Secrets["API_KEY"])
+}
+
+func TestNewRunContextDeploymentEnvironmentExpression(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+jobs:
+  deploy:
+    environment: ${{ matrix.target }}
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        target: [staging]
+    steps:
+    - run: echo
+`))
+	assert.NoError(t, err)
+
+	runner := &runnerImpl{
+		config: &Config{
+			Secrets: map[string]string{"API_KEY": "repo-key"},
+			EnvironmentSecrets: map[string]map[string]string{
+				"staging":    {"API_KEY": "staging-key"},
+				"production": {"API_KEY": "production-key"},
+			},
+		},
+	}
+	ctx := context.Background()
+	rc := runner.newRunContext(ctx, &model.Run{JobID: "deploy", Workflow: workflow}, map[string]interface{}{"target": "staging"})
+
+	assert.Equal(t, "staging", rc.DeploymentEnvironment(ctx).Name)
+	assert.Equal(t, "staging-key", rc.ExprEval.Interpolate(ctx, "${{ secrets.API_KEY }}"))
+	assert.Contains(t, rc.Masks, "staging-key")
+	assert.NotContains(t, rc.Masks, "production-key", "only the secrets of the resolved environment are masked")
+
+	// the name can't select an environment by a secret
+	workflow.Jobs["deploy"].RawEnvironment.Value = "${{ secrets.API_KEY == 'repo-key' && 'production' || 'staging' }}"
+	rc = runner.newRunContext(ctx, &model.Run{JobID: "deploy", Workflow: workflow}, nil)
+	assert.Equal(t, "staging", rc.DeploymentEnvironment(ctx).Name)
+}
+
+func TestRunEventServicesFailedStart(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	workdir, err := filepath.Abs(workdir)
+	assert.NoError(t, err)
+
+	runner, err := New(&Config{
+		Workdir:             workdir,
+		EventName:           "push",
+		Platforms:           platforms,
+		ContainerNamePrefix: "act-failed-start",
+		GitHubInstance:      "github.com",
+	})
+	assert.NoError(t, err)
+
+	planner, err := model.NewWorkflowPlanner(filepath.Join(workdir, "services-failed-start"), true)
+	assert.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	assert.NoError(t, err)
+
+	assert.Error(t, runner.NewPlanExecutor(plan)(ctx))
+
+	// the network created for the services is removed although the job container never started
+	cli, err := container.GetDockerClient(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer cli.Close()
+	networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
+	assert.NoError(t, err)
+	for _, network := range networks {
+		assert.False(t, strings.HasPrefix(network.Name, "act-failed-start"), network.Name)
+	}
+}
+
 func TestRunEventSecrets(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -645,3 +841,668 @@ func TestRunMatrixWithUserDefinedInclusions(t *testing.T) {
 
 	tjfi.runTest(context.Background(), t, &Config{Matrix: matrix})
 }
+
+func TestRunnerShouldRunForEvent(t *testing.T) {
+	pullRequest, err := model.ReadWorkflow(strings.NewReader(`
+on:
+  pull_request:
+    types: [opened]
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+`))
+	assert.NoError(t, err)
+	push, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+`))
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		action   string
+		workflow *model.Workflow
+		run      bool
+	}{
+		{"opened", pullRequest, true},
+		{"closed", pullRequest, false},
+		// workflows not listening to the event are planned by job and always run
+		{"closed", push, true},
+	} {
+		runner := &runnerImpl{
+			config:    &Config{EventName: "pull_request"},
+			eventJSON: fmt.Sprintf(`{"action": %q}`, tt.action),
+		}
+		assert.Equal(t, tt.run, runner.shouldRunForEvent(tt.workflow, runner.eventActivityType()), "%s %v", tt.action, tt.workflow.On())
+	}
+}
+
+func TestRunnerNewMatrixExecutor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the step is a shell script")
+	}
+
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        os: [linux, windows, macos]
+        node: [16, 18]
+    steps:
+    - run: echo "leg ${{ matrix.os }}-${{ matrix.node }}"
+`))
+	assert.NoError(t, err)
+
+	capture := &bytes.Buffer{}
+	runner, err := New(&Config{
+		Workdir:        t.TempDir(),
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "-self-hosted",
+		},
+		GitHubInstance: "github.com",
+		OutputCapture:  capture,
+	})
+	assert.NoError(t, err)
+
+	run := &model.Run{JobID: "test", Workflow: workflow}
+	err = runner.(MatrixRunner).NewMatrixExecutor(run, map[string]interface{}{"os": "windows", "node": 18})(context.Background())
+	assert.NoError(t, err)
+
+	assert.Contains(t, capture.String(), "leg windows-18\n")
+	assert.Equal(t, 1, strings.Count(capture.String(), "leg "), capture.String())
+	assert.Equal(t, "success", run.Job().Result)
+}
+
+// failingEnvironment is a RecordingEnvironment whose commands fail
+type failingEnvironment struct {
+	*container.RecordingEnvironment
+}
+
+func (e *failingEnvironment) Exec(command []string, env map[string]string, user, workdir string) common.Executor {
+	record := e.RecordingEnvironment.Exec(command, env, user, workdir)
+	return func(ctx context.Context) error {
+		if err := record(ctx); err != nil {
+			return err
+		}
+		return errors.New("exit status 1")
+	}
+}
+
+func TestRunnerNewMatrixExecutorFailure(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        node: [16, 18]
+    steps:
+    - run: exit 1
+`))
+	assert.NoError(t, err)
+
+	r, err := New(&Config{
+		Workdir:        "/work",
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+		},
+		GitHubInstance: "github.com",
+		JobEnvironment: &failingEnvironment{&container.RecordingEnvironment{}},
+	})
+	assert.NoError(t, err)
+
+	run := &model.Run{JobID: "test", Workflow: workflow}
+	err = r.(MatrixRunner).NewMatrixExecutor(run, map[string]interface{}{"node": 18})(context.Background())
+	assert.EqualError(t, err, "exit status 1")
+	assert.Equal(t, "failure", run.Job().Result)
+}
+
+func TestRunnerUniqueContainerNames(t *testing.T) {
+	ctx := context.Background()
+	run := &model.Run{
+		JobID: "job1",
+		Workflow: &model.Workflow{
+			Name: "test-workflow",
+			Jobs: map[string]*model.Job{
+				"job1": {Name: "job1"},
+			},
+		},
+	}
+	newRC := func(config *Config) *RunContext {
+		r, err := New(config)
+		assert.NoError(t, err)
+		return r.(*runnerImpl).newRunContext(ctx, run, nil)
+	}
+
+	config := &Config{ContainerNamePrefix: "ci", UniqueContainerNames: true}
+	rc1 := newRC(config)
+	rc2 := newRC(config)
+	assert.True(t, strings.HasPrefix(rc1.jobContainerName(), "ci-"))
+	assert.NotEqual(t, rc1.jobContainerName(), rc2.jobContainerName())
+
+	// stable within a run
+	r, err := New(config)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		r.(*runnerImpl).newRunContext(ctx, run, nil).jobContainerName(),
+		r.(*runnerImpl).newRunContext(ctx, run, nil).jobContainerName())
+
+	// reused containers keep their names across runs
+	config = &Config{ContainerNamePrefix: "ci", UniqueContainerNames: true, ReuseContainers: true}
+	assert.Equal(t, newRC(config).jobContainerName(), newRC(config).jobContainerName())
+	assert.Equal(t, createContainerName("act", "test-workflow/job1"), newRC(&Config{}).jobContainerName())
+}
+
+func TestNewWorkflowExecutor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the step is a shell script")
+	}
+
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: in-memory
+on: push
+jobs:
+  hello:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo "hello from memory"
+`))
+	assert.NoError(t, err)
+
+	capture := &bytes.Buffer{}
+	executor, err := NewWorkflowExecutor(&Config{
+		Workdir:        t.TempDir(),
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "-self-hosted",
+		},
+		GitHubInstance: "github.com",
+		OutputCapture:  capture,
+	}, workflow)
+	assert.NoError(t, err)
+	assert.NoError(t, executor(context.Background()))
+
+	assert.Equal(t, "hello from memory\n", capture.String())
+	assert.Equal(t, "success", workflow.GetJob("hello").Result)
+
+	_, err = NewWorkflowExecutor(&Config{}, &model.Workflow{Jobs: map[string]*model.Job{"1-invalid": {}}})
+	assert.Error(t, err)
+}
+
+func TestRunnerRecordingEnvironment(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: recorded
+on: push
+env:
+  GREETING: hello
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo "$GREETING ${{ github.event_name }}"
+    - if: false
+      run: echo skipped
+    - run: echo second
+      shell: sh
+      working-directory: sub
+`))
+	assert.NoError(t, err)
+
+	recorder := &container.RecordingEnvironment{}
+	executor, err := NewWorkflowExecutor(&Config{
+		Workdir:        "/work",
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+		},
+		GitHubInstance: "github.com",
+		JobEnvironment: recorder,
+	}, workflow)
+	assert.NoError(t, err)
+	assert.NoError(t, executor(context.Background()))
+	assert.Equal(t, "success", workflow.GetJob("build").Result)
+
+	execs := recorder.Execs()
+	if assert.Len(t, execs, 2) {
+		assert.Equal(t, []string{"bash", "--noprofile", "--norc", "-e", "-o", "pipefail", "/var/run/act/workflow/0"}, execs[0].Command)
+		assert.Equal(t, "hello", execs[0].Env["GREETING"])
+		assert.Equal(t, []string{"sh", "-e", "/var/run/act/workflow/2.sh"}, execs[1].Command)
+		assert.Equal(t, "sub", execs[1].WorkDir)
+	}
+	files := recorder.Files()
+	assert.Contains(t, files["/var/run/act/workflow/0"], `echo "$GREETING push"`)
+	assert.Contains(t, files["/var/run/act/workflow/2.sh"], "echo second")
+	assert.NotContains(t, files, "/var/run/act/workflow/1")
+}
+
+func TestRunnerDefaultMaxParallel(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: max-parallel
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        version: [1, 2, 3]
+    steps:
+    - run: echo ${{ strategy.max-parallel }}
+`))
+	assert.NoError(t, err)
+
+	recorder := &container.RecordingEnvironment{}
+	executor, err := NewWorkflowExecutor(&Config{
+		Workdir:        "/work",
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+		},
+		GitHubInstance:     "github.com",
+		DefaultMaxParallel: 7,
+		JobEnvironment:     recorder,
+	}, workflow)
+	assert.NoError(t, err)
+	assert.NoError(t, executor(context.Background()))
+	assert.Equal(t, 7, workflow.GetJob("build").Strategy.MaxParallel)
+	assert.Contains(t, recorder.Files()["/var/run/act/workflow/0"], "echo 7")
+}
+
+func TestRunnerSecretEnvPrefix(t *testing.T) {
+	t.Setenv("ACT_SECRET_FOO", "bar")
+	t.Setenv("ACT_SECRET_GITHUB_TOKEN", "env-token")
+	t.Setenv("ACT_SECRET_", "ignored")
+
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: secrets
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo ${{ secrets.FOO }} ${{ secrets.BAZ }} ${{ github.token }}
+`))
+	assert.NoError(t, err)
+
+	recorder := &container.RecordingEnvironment{}
+	config := &Config{
+		Workdir:        "/work",
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+		},
+		GitHubInstance:  "github.com",
+		SecretEnvPrefix: "ACT_SECRET_",
+		Secrets:         map[string]string{"BAZ": "qux"},
+		JobEnvironment:  recorder,
+	}
+	executor, err := NewWorkflowExecutor(config, workflow)
+	assert.NoError(t, err)
+	assert.NoError(t, executor(context.Background()))
+
+	// the config of the caller is left alone
+	assert.Equal(t, map[string]string{"BAZ": "qux"}, config.Secrets)
+	assert.Equal(t, "", config.Token)
+	assert.Contains(t, recorder.Files()["/var/run/act/workflow/0"], "echo bar qux env-token")
+
+	r, err := New(config)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"FOO": "bar", "BAZ": "qux", "GITHUB_TOKEN": "env-token"}, r.(*runnerImpl).config.Secrets)
+	assert.Equal(t, "env-token", r.(*runnerImpl).config.Token)
+	assert.Equal(t, "token ***", maskValues("token bar", r.(*runnerImpl).config.Secrets, nil))
+}
+
+func TestRunnerLintRunsOn(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: runs-on
+on: push
+jobs:
+  gpu:
+    runs-on: gpu-cluster
+    steps:
+    - run: echo
+  tpu:
+    runs-on: tpu-cluster
+    steps:
+    - run: echo
+`))
+	assert.NoError(t, err)
+	planner, err := model.NewWorkflowPlannerFromWorkflow(workflow)
+	assert.NoError(t, err)
+	plan, err := planner.PlanAll()
+	assert.NoError(t, err)
+
+	out := log.StandardLogger().Out
+	defer log.SetOutput(out)
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+
+	r, err := New(&Config{Platforms: map[string]string{"gpu-cluster": "node:16-buster-slim"}})
+	assert.NoError(t, err)
+	r.NewPlanExecutor(plan)
+
+	assert.Contains(t, buf.String(), "unknown label 'tpu-cluster', did you mean 'gpu-cluster'?")
+	assert.NotContains(t, buf.String(), "unknown label 'gpu-cluster'")
+}
+
+func TestRunnerEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.env")
+	override := filepath.Join(dir, "override.env")
+	assert.NoError(t, os.WriteFile(base, []byte("GREETING=hello\nNAME=base\nTARGET=base\n"), 0o600))
+	assert.NoError(t, os.WriteFile(override, []byte("NAME=override\nTARGET=override\n"), 0o600))
+
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: env-files
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+`))
+	assert.NoError(t, err)
+
+	recorder := &container.RecordingEnvironment{}
+	executor, err := NewWorkflowExecutor(&Config{
+		Workdir:        "/work",
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+		},
+		GitHubInstance: "github.com",
+		Env:            map[string]string{"TARGET": "explicit"},
+		EnvFiles:       []string{base, override},
+		JobEnvironment: recorder,
+	}, workflow)
+	assert.NoError(t, err)
+	assert.NoError(t, executor(context.Background()))
+
+	execs := recorder.Execs()
+	if assert.Len(t, execs, 1) {
+		assert.Equal(t, "hello", execs[0].Env["GREETING"])
+		assert.Equal(t, "override", execs[0].Env["NAME"])
+		assert.Equal(t, "explicit", execs[0].Env["TARGET"])
+	}
+
+	_, err = New(&Config{EnvFiles: []string{filepath.Join(dir, "missing.env")}})
+	assert.Error(t, err)
+}
+
+// blockingEnvironment is a RecordingEnvironment whose commands run until their
+// job is released or cancelled
+type blockingEnvironment struct {
+	*container.RecordingEnvironment
+	started chan string
+	release map[string]chan struct{} // by job id, jobs without one only end when cancelled
+	removed chan string
+}
+
+func (e *blockingEnvironment) Exec(command []string, env map[string]string, user, workdir string) common.Executor {
+	record := e.RecordingEnvironment.Exec(command, env, user, workdir)
+	return func(ctx context.Context) error {
+		if err := record(ctx); err != nil {
+			return err
+		}
+		e.started <- env["GITHUB_JOB"]
+		select {
+		case <-e.release[env["GITHUB_JOB"]]:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (e *blockingEnvironment) Remove() common.Executor {
+	return func(ctx context.Context) error {
+		if entry, ok := common.Logger(ctx).(*log.Entry); ok {
+			e.removed <- fmt.Sprint(entry.Data["jobID"])
+		}
+		return nil
+	}
+}
+
+// receive returns the next value of ch, failing the test if there is none in time
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the runner")
+		var zero T
+		return zero
+	}
+}
+
+func TestRunnerCancelJob(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: cancel
+on: push
+jobs:
+  cancelled:
+    runs-on: ubuntu-latest
+    steps:
+    - run: sleep 600
+  completed:
+    runs-on: ubuntu-latest
+    steps:
+    - run: sleep 600
+`))
+	assert.NoError(t, err)
+	planner, err := model.NewWorkflowPlannerFromWorkflow(workflow)
+	assert.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	assert.NoError(t, err)
+
+	env := &blockingEnvironment{
+		RecordingEnvironment: &container.RecordingEnvironment{},
+		started:              make(chan string, 2),
+		release:              map[string]chan struct{}{"completed": make(chan struct{})},
+		removed:              make(chan string, 2),
+	}
+	r, err := New(&Config{
+		Workdir:        "/work",
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+		},
+		GitHubInstance: "github.com",
+		JobEnvironment: env,
+	})
+	assert.NoError(t, err)
+	canceller := r.(JobCanceller)
+	assert.False(t, canceller.CancelJob("cancelled"), "the job isn't running yet")
+
+	// "completed" ends on its own, "cancelled" only once it is cancelled
+	close(env.release["completed"])
+	done := make(chan error)
+	go func() {
+		done <- r.NewPlanExecutor(plan)(context.Background())
+	}()
+	for receive(t, env.started) != "cancelled" { // "completed" may start first
+	}
+	assert.True(t, canceller.CancelJob("cancelled"))
+	assert.NoError(t, receive(t, done))
+	assert.ElementsMatch(t, []string{"cancelled", "completed"}, []string{receive(t, env.removed), receive(t, env.removed)}, "the containers of both jobs are removed")
+	assert.False(t, r.(*runnerImpl).cancels.isCancelled("cancelled"), "the cancellation is forgotten once the job is done")
+
+	assert.Equal(t, "cancelled", workflow.GetJob("cancelled").Result)
+	assert.Equal(t, "success", workflow.GetJob("completed").Result)
+	assert.Equal(t, map[string]string{"cancelled": "cancelled", "completed": "success"}, NewRunResult(context.Background(), plan).Jobs)
+}
+
+func TestRunnerCancelJobPendingLegs(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: cancel
+on: push
+jobs:
+  cancelled:
+    runs-on: ubuntu-latest
+    strategy:
+      max-parallel: 1
+      matrix:
+        leg: [1, 2, 3]
+    steps:
+    - run: sleep 600
+`))
+	assert.NoError(t, err)
+	planner, err := model.NewWorkflowPlannerFromWorkflow(workflow)
+	assert.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	assert.NoError(t, err)
+
+	env := &blockingEnvironment{
+		RecordingEnvironment: &container.RecordingEnvironment{},
+		started:              make(chan string, 3),
+		removed:              make(chan string, 3),
+	}
+	r, err := New(&Config{
+		Workdir:        "/work",
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+		},
+		GitHubInstance: "github.com",
+		JobEnvironment: env,
+	})
+	assert.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- r.NewPlanExecutor(plan)(context.Background())
+	}()
+	assert.Equal(t, "cancelled", receive(t, env.started))
+	assert.True(t, r.(JobCanceller).CancelJob("cancelled"))
+	assert.NoError(t, receive(t, done))
+	assert.Equal(t, "cancelled", receive(t, env.removed), "the container of the cancelled leg is removed")
+
+	// the legs waiting for max-parallel didn't start
+	assert.Len(t, env.started, 0)
+	assert.Len(t, env.removed, 0)
+	assert.Equal(t, "cancelled", workflow.GetJob("cancelled").Result)
+}
+
+func TestRunnerShowTimestamps(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: timestamps
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+`))
+	assert.NoError(t, err)
+
+	capture := &bytes.Buffer{}
+	recorder := &container.RecordingEnvironment{
+		Output: func(container.RecordedExec) string {
+			return "::add-mask::s3cr3t\nthe secret is s3cr3t\n"
+		},
+	}
+	executor, err := NewWorkflowExecutor(&Config{
+		Workdir:        "/work",
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+		},
+		GitHubInstance: "github.com",
+		ShowTimestamps: true,
+		OutputCapture:  capture,
+		JobEnvironment: recorder,
+	}, workflow)
+	assert.NoError(t, err)
+	assert.NoError(t, executor(context.Background()))
+
+	// the workflow commands are processed before the timestamp is added
+	assert.Contains(t, capture.String(), "the secret is ***\n")
+	assert.NotContains(t, capture.String(), "s3cr3t")
+
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	entry := log.NewEntry(logger).WithField("job", "build").WithField("raw_output", true)
+	entry.Time = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry.Message = "the secret is ***"
+	formatted, err := (&jobLogFormatter{showTimestamps: true}).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "[build]   | 2024-01-02T03:04:05Z the secret is ***\n", string(formatted))
+}
+
+func TestRunnerOutputCapture(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: output-capture
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo first
+      env:
+        STEP: first
+    - run: echo second
+      env:
+        STEP: second
+`))
+	assert.NoError(t, err)
+
+	capture := &bytes.Buffer{}
+	recorder := &container.RecordingEnvironment{
+		Output: func(exec container.RecordedExec) string {
+			switch exec.Env["STEP"] {
+			case "first":
+				return "hello world\nthe token is secret-token\n::add-mask::masked-value\n"
+			case "second":
+				return "echo masked-value\npartial line\n"
+			}
+			return ""
+		},
+	}
+	executor, err := NewWorkflowExecutor(&Config{
+		Workdir:        "/work",
+		ActionCacheDir: t.TempDir(),
+		EventName:      "push",
+		Platforms: map[string]string{
+			"ubuntu-latest": "node:16-buster-slim",
+		},
+		GitHubInstance: "github.com",
+		Secrets:        map[string]string{"TOKEN": "secret-token"},
+		OutputCapture:  capture,
+		JobEnvironment: recorder,
+	}, workflow)
+	assert.NoError(t, err)
+	assert.NoError(t, executor(context.Background()))
+
+	// the output of the steps in order, with the secrets and the masks of earlier steps masked
+	assert.Equal(t, "hello world\n"+
+		"the token is ***\n"+
+		"echo ***\n"+
+		"partial line\n", capture.String())
+}
@@ -0,0 +1,54 @@
+package runner
+
+import "strings"
+
+// SecretResolver resolves a secret by its name when it is referenced,
+// e.g. by fetching it from a vault
+type SecretResolver interface {
+	GetSecret(name string) (string, bool)
+}
+
+// SecretMap is a SecretResolver of secrets known upfront
+type SecretMap map[string]string
+
+// GetSecret returns the secret, names are case insensitive
+func (m SecretMap) GetSecret(name string) (string, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// secretLookup returns the lookup for secrets which aren't part of Config.Secrets.
+// Secret names are case insensitive, the resolver gets them in upper case like
+// GitHub stores them. Every secret is resolved at most once per job and its value is masked.
+// Called reusable workflows only resolve secrets if every caller inherits its secrets,
+// otherwise they get just the secrets passed explicitly.
+func (rc *RunContext) secretLookup() func(name string) (string, bool) {
+	if rc.Config.SecretResolver == nil {
+		return nil
+	}
+	for c := rc.caller; c != nil; c = c.runContext.caller {
+		if !c.runContext.Run.Job().InheritSecrets() {
+			return nil
+		}
+	}
+	return func(name string) (string, bool) {
+		key := strings.ToUpper(name)
+		if v, ok := rc.resolvedSecrets[key]; ok {
+			return v, true
+		}
+		v, ok := rc.Config.SecretResolver.GetSecret(key)
+		if !ok {
+			return "", false
+		}
+		if rc.resolvedSecrets == nil {
+			rc.resolvedSecrets = map[string]string{}
+		}
+		rc.resolvedSecrets[key] = v
+		rc.AddMask(v)
+		return v, true
+	}
+}
@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+type countingSecretResolver struct {
+	secrets SecretMap
+	fetched []string
+}
+
+func (r *countingSecretResolver) GetSecret(name string) (string, bool) {
+	r.fetched = append(r.fetched, name)
+	return r.secrets.GetSecret(name)
+}
+
+func TestSecretResolver(t *testing.T) {
+	resolver := &countingSecretResolver{
+		secrets: SecretMap{
+			"DB_PASSWORD": "vault-db-password",
+			"API_KEY":     "vault-api-key",
+			"UNUSED":      "vault-unused",
+		},
+	}
+	rc := &RunContext{
+		Config: &Config{
+			Secrets:        map[string]string{"STATIC": "static-value"},
+			SecretResolver: resolver,
+		},
+		Run: &model.Run{
+			JobID: "job1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{"job1": {}},
+			},
+		},
+	}
+	ctx := context.Background()
+	ee := rc.NewExpressionEvaluator(ctx)
+
+	assert.Equal(t, "static-value", ee.Interpolate(ctx, "${{ secrets.STATIC }}"))
+	assert.Equal(t, "vault-db-password", ee.Interpolate(ctx, "${{ secrets.DB_PASSWORD }}"))
+	assert.Equal(t, "vault-db-password", ee.Interpolate(ctx, "${{ secrets.db_password }}"))
+	assert.Equal(t, "vault-api-key", ee.Interpolate(ctx, "${{ secrets['API_KEY'] }}"))
+	assert.Equal(t, "", ee.Interpolate(ctx, "${{ secrets.MISSING }}"))
+
+	assert.Equal(t, []string{"DB_PASSWORD", "API_KEY", "MISSING"}, resolver.fetched)
+	assert.Equal(t, []string{"vault-db-password", "vault-api-key"}, rc.Masks)
+	assert.Equal(t, "{\n  \"STATIC\": \"static-value\"\n}", ee.Interpolate(ctx, "${{ toJSON(secrets) }}"))
+}
+
+func TestSecretMap(t *testing.T) {
+	secrets := SecretMap{"TOKEN": "value"}
+
+	v, ok := secrets.GetSecret("token")
+	assert.True(t, ok)
+	assert.Equal(t, "value", v)
+
+	_, ok = secrets.GetSecret("other")
+	assert.False(t, ok)
+}
+
+func TestSecretResolverReusableWorkflow(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+jobs:
+  inherit:
+    uses: ./.github/workflows/called.yml
+    secrets: inherit
+  explicit:
+    uses: ./.github/workflows/called.yml
+    secrets:
+      STATIC: ${{ secrets.STATIC }}
+`))
+	assert.NoError(t, err)
+
+	config := &Config{
+		Secrets:        map[string]string{"STATIC": "static-value"},
+		SecretResolver: SecretMap{"API_KEY": "vault-api-key"},
+	}
+	ctx := context.Background()
+	newCalledRunContext := func(callerJobID string) *RunContext {
+		callerRc := &RunContext{
+			Config: config,
+			Run:    &model.Run{JobID: callerJobID, Workflow: workflow},
+		}
+		callerRc.ExprEval = callerRc.NewExpressionEvaluator(ctx)
+		return &RunContext{
+			Config: config,
+			Run: &model.Run{
+				JobID: "called",
+				Workflow: &model.Workflow{
+					Jobs: map[string]*model.Job{"called": {}},
+				},
+			},
+			caller: &caller{runContext: callerRc},
+		}
+	}
+
+	rc := newCalledRunContext("inherit")
+	ee := rc.NewExpressionEvaluator(ctx)
+	assert.Equal(t, "static-value", ee.Interpolate(ctx, "${{ secrets.STATIC }}"))
+	assert.Equal(t, "vault-api-key", ee.Interpolate(ctx, "${{ secrets.API_KEY }}"))
+	assert.Equal(t, []string{"vault-api-key"}, rc.Masks)
+
+	rc = newCalledRunContext("explicit")
+	ee = rc.NewExpressionEvaluator(ctx)
+	assert.Equal(t, "static-value", ee.Interpolate(ctx, "${{ secrets.STATIC }}"))
+	assert.Equal(t, "", ee.Interpolate(ctx, "${{ secrets.API_KEY }}"))
+}
@@ -2,8 +2,11 @@ package runner
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -49,18 +52,89 @@ func (s stepStage) String() string {
 	return "Unknown"
 }
 
-func processRunnerEnvFileCommand(ctx context.Context, fileName string, rc *RunContext, setter func(context.Context, map[string]string, string)) error {
+// Maximum total size in bytes of the variables a step may set via GITHUB_ENV, unless configured otherwise
+const defaultEnvFileSizeLimit = 1024 * 1024
+
+var envFileNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedEnvNames returns the variables act provides to every step, GITHUB_ENV can't overwrite them
+func reservedEnvNames(ctx context.Context, rc *RunContext) map[string]bool {
+	names := map[string]bool{
+		"NODE_OPTIONS":        true,
+		"GITHUB_OUTPUT":       true,
+		"GITHUB_STATE":        true,
+		"GITHUB_PATH":         true,
+		"GITHUB_ENV":          true,
+		"GITHUB_STEP_SUMMARY": true,
+	}
+	for k := range rc.withGithubEnv(ctx, &model.GithubContext{}, map[string]string{}) {
+		if strings.HasPrefix(k, "GITHUB_") {
+			names[k] = true
+		}
+	}
+	return names
+}
+
+// validateEnvFile rejects GITHUB_ENV contents GitHub wouldn't accept
+func validateEnvFile(env map[string]string, sizeLimit int, reserved map[string]bool) error {
+	if sizeLimit <= 0 {
+		sizeLimit = defaultEnvFileSizeLimit
+	}
+	size := 0
+	for k, v := range env {
+		if !envFileNameRegex.MatchString(k) {
+			return fmt.Errorf("invalid environment variable name '%s' in GITHUB_ENV: names must start with a letter or '_' and contain only alphanumeric characters or '_'", k)
+		}
+		if reserved[strings.ToUpper(k)] {
+			return fmt.Errorf("environment variable '%s' can't be set via GITHUB_ENV", k)
+		}
+		size += len(k) + len(v)
+	}
+	if size > sizeLimit {
+		return fmt.Errorf("GITHUB_ENV exceeds the size limit of %d bytes (%d bytes)", sizeLimit, size)
+	}
+	return nil
+}
+
+func processRunnerEnvFileCommand(ctx context.Context, fileName string, rc *RunContext, setter func(context.Context, map[string]string, string), validate func(map[string]string) error) error {
 	env := map[string]string{}
-	err := rc.JobContainer.UpdateFromEnv(path.Join(rc.JobContainer.GetActPath(), fileName), &env)(ctx)
+	err := rc.JobContainer.UpdateFromEnv(rc.joinActPath(rc.JobContainer.GetActPath(), fileName), &env)(ctx)
 	if err != nil {
 		return err
 	}
+	if validate != nil {
+		if err := validate(env); err != nil {
+			return err
+		}
+	}
 	for k, v := range env {
 		setter(ctx, map[string]string{"name": k}, v)
 	}
 	return nil
 }
 
+// joinActPath joins a slash separated path to a directory of the job container
+// with the path separator of its platform, otherwise the file commands of
+// windows containers aren't found
+func (rc *RunContext) joinActPath(dir string, name string) string {
+	if rc.JobContainer.IsEnvironmentCaseInsensitive() {
+		return strings.ReplaceAll(path.Join(strings.ReplaceAll(dir, `\`, "/"), name), "/", `\`)
+	}
+	return path.Join(dir, name)
+}
+
+// fileCommandName returns the path of a runner file command relative to the act path
+// Random names prevent a step from pre-seeding the file commands of another step
+func fileCommandName(rc *RunContext, name string) string {
+	if rc.Config.RandomizeFileCommands {
+		randBytes := make([]byte, 8)
+		_, _ = rand.Read(randBytes)
+		ext := path.Ext(name)
+		name = fmt.Sprintf("%s-%s%s", strings.TrimSuffix(name, ext), hex.EncodeToString(randBytes), ext)
+	}
+	return path.Join("workflow", name)
+}
+
 func runStepExecutor(step step, stage stepStage, executor common.Executor) common.Executor {
 	return func(ctx context.Context) error {
 		logger := common.Logger(ctx)
@@ -107,22 +181,22 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 		// Prepare and clean Runner File Commands
 		actPath := rc.JobContainer.GetActPath()
 
-		outputFileCommand := path.Join("workflow", "outputcmd.txt")
-		(*step.getEnv())["GITHUB_OUTPUT"] = path.Join(actPath, outputFileCommand)
+		outputFileCommand := fileCommandName(rc, "outputcmd.txt")
+		(*step.getEnv())["GITHUB_OUTPUT"] = rc.joinActPath(actPath, outputFileCommand)
 
-		stateFileCommand := path.Join("workflow", "statecmd.txt")
-		(*step.getEnv())["GITHUB_STATE"] = path.Join(actPath, stateFileCommand)
+		stateFileCommand := fileCommandName(rc, "statecmd.txt")
+		(*step.getEnv())["GITHUB_STATE"] = rc.joinActPath(actPath, stateFileCommand)
 
-		pathFileCommand := path.Join("workflow", "pathcmd.txt")
-		(*step.getEnv())["GITHUB_PATH"] = path.Join(actPath, pathFileCommand)
+		pathFileCommand := fileCommandName(rc, "pathcmd.txt")
+		(*step.getEnv())["GITHUB_PATH"] = rc.joinActPath(actPath, pathFileCommand)
 
-		envFileCommand := path.Join("workflow", "envs.txt")
-		(*step.getEnv())["GITHUB_ENV"] = path.Join(actPath, envFileCommand)
+		envFileCommand := fileCommandName(rc, "envs.txt")
+		(*step.getEnv())["GITHUB_ENV"] = rc.joinActPath(actPath, envFileCommand)
 
-		summaryFileCommand := path.Join("workflow", "SUMMARY.md")
-		(*step.getEnv())["GITHUB_STEP_SUMMARY"] = path.Join(actPath, summaryFileCommand)
+		summaryFileCommand := fileCommandName(rc, "SUMMARY.md")
+		(*step.getEnv())["GITHUB_STEP_SUMMARY"] = rc.joinActPath(actPath, summaryFileCommand)
 
-		_ = rc.JobContainer.Copy(actPath, &container.FileEntry{
+		_ = rc.copyToJobContainer(actPath, &container.FileEntry{
 			Name: outputFileCommand,
 			Mode: 0o666,
 		}, &container.FileEntry{
@@ -141,12 +215,28 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 
 		timeoutctx, cancelTimeOut := evaluateStepTimeout(ctx, rc.ExprEval, stepModel)
 		defer cancelTimeOut()
+		rc.workflowCommandErr = nil
+		if rc.Config.StepOutputTail > 0 && rc.Parent == nil {
+			if rc.stepOutputTail == nil {
+				rc.stepOutputTail = newOutputTail(rc.Config.StepOutputTail)
+			}
+			rc.stepOutputTail.reset()
+		}
+		start := time.Now()
 		err = executor(timeoutctx)
+		stepResult.Duration = time.Since(start)
+		logger = logger.WithField("duration", stepResult.Duration)
+		if err == nil && rc.workflowCommandErr != nil {
+			err = rc.workflowCommandErr
+		}
 
 		if err == nil {
 			logger.WithField("stepResult", stepResult.Outcome).Infof("  \u2705  Success - %s %s", stage, stepString)
 		} else {
 			stepResult.Outcome = model.StepStatusFailure
+			if rc.stepOutputTail != nil && rc.Parent == nil {
+				stepResult.OutputTail = rc.stepOutputTail.Lines()
+			}
 
 			continueOnError, parseErr := isContinueOnError(ctx, stepModel.RawContinueOnError, step, stage)
 			if parseErr != nil {
@@ -166,19 +256,21 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 		}
 		// Process Runner File Commands
 		orgerr := err
-		err = processRunnerEnvFileCommand(ctx, envFileCommand, rc, rc.setEnv)
+		err = processRunnerEnvFileCommand(ctx, envFileCommand, rc, rc.setEnv, func(env map[string]string) error {
+			return validateEnvFile(env, rc.Config.EnvFileSizeLimit, reservedEnvNames(ctx, rc))
+		})
 		if err != nil {
 			return err
 		}
-		err = processRunnerEnvFileCommand(ctx, stateFileCommand, rc, rc.saveState)
+		err = processRunnerEnvFileCommand(ctx, stateFileCommand, rc, rc.saveState, nil)
 		if err != nil {
 			return err
 		}
-		err = processRunnerEnvFileCommand(ctx, outputFileCommand, rc, rc.setOutput)
+		err = processRunnerEnvFileCommand(ctx, outputFileCommand, rc, rc.setOutput, nil)
 		if err != nil {
 			return err
 		}
-		err = rc.UpdateExtraPath(ctx, path.Join(actPath, pathFileCommand))
+		err = rc.UpdateExtraPath(ctx, rc.joinActPath(actPath, pathFileCommand))
 		if err != nil {
 			return err
 		}
@@ -202,8 +294,9 @@ func evaluateStepTimeout(ctx context.Context, exprEval ExpressionEvaluator, step
 func setupEnv(ctx context.Context, step step) error {
 	rc := step.getRunContext()
 
+	// the job env including the variables persisted via GITHUB_ENV forms the base
 	mergeEnv(ctx, step)
-	// merge step env last, since it should not be overwritten
+	// merge step env last, since it should not be overwritten, not even by GITHUB_ENV
 	mergeIntoMap(step, step.getEnv(), step.getStepModel().GetEnv())
 
 	exprEval := rc.NewExpressionEvaluator(ctx)
@@ -233,7 +326,13 @@ func mergeEnv(ctx context.Context, step step) {
 
 	c := job.Container()
 	if c != nil {
-		mergeIntoMap(step, env, rc.GetEnv(), c.Env)
+		// secrets resolved here are masked by the job logger like any other secret value
+		exprEval := rc.NewExpressionEvaluator(ctx)
+		containerEnv := make(map[string]string, len(c.Env))
+		for k, v := range c.Env {
+			containerEnv[k] = exprEval.Interpolate(ctx, v)
+		}
+		mergeIntoMap(step, env, rc.GetEnv(), containerEnv)
 	} else {
 		mergeIntoMap(step, env, rc.GetEnv())
 	}
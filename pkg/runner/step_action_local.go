@@ -9,7 +9,6 @@ import (
 	"io/fs"
 	"os"
 	"path"
-	"path/filepath"
 
 	"github.com/nektos/act/pkg/common"
 	"github.com/nektos/act/pkg/model"
@@ -40,7 +39,14 @@ func (sal *stepActionLocal) main() common.Executor {
 			return nil
 		}
 
-		actionDir := filepath.Join(sal.getRunContext().Config.Workdir, sal.Step.Uses)
+		actionDir := sal.RunContext.localActionDir(sal.Step.Uses)
+		if !sal.RunContext.isInWorkdir(actionDir) {
+			// the action is not part of the workspace, copy it next to it
+			_, cpath := getContainerActionPaths(sal.Step, actionDir, sal.RunContext)
+			if err := sal.RunContext.JobContainer.CopyDir(cpath+"/", actionDir+"/", sal.RunContext.Config.UseGitIgnore)(ctx); err != nil {
+				return err
+			}
+		}
 
 		localReader := func(ctx context.Context) actionYamlReader {
 			_, cpath := getContainerActionPaths(sal.Step, path.Join(actionDir, ""), sal.RunContext)
@@ -120,7 +126,7 @@ func (sal *stepActionLocal) getActionModel() *model.Action {
 
 func (sal *stepActionLocal) getCompositeRunContext(ctx context.Context) *RunContext {
 	if sal.compositeRunContext == nil {
-		actionDir := filepath.Join(sal.RunContext.Config.Workdir, sal.Step.Uses)
+		actionDir := sal.RunContext.localActionDir(sal.Step.Uses)
 		_, containerActionDir := getContainerActionPaths(sal.getStepModel(), actionDir, sal.RunContext)
 
 		sal.compositeRunContext = newCompositeRunContext(ctx, sal.RunContext, sal, containerActionDir)
@@ -197,6 +197,10 @@ func TestStepActionRemote(t *testing.T) {
 
 			assert.Equal(t, tt.runError, err)
 			assert.Equal(t, tt.mocks.cloned, clonedAction)
+			if result := sar.RunContext.StepResults["step"]; result != nil {
+				// the duration differs between runs
+				result.Duration = 0
+			}
 			assert.Equal(t, tt.result, sar.RunContext.StepResults["step"])
 
 			sarm.AssertExpectations(t)
@@ -413,6 +417,100 @@ func TestStepActionRemotePreThroughActionToken(t *testing.T) {
 	}
 }
 
+func TestStepActionRemotePreEnvToMain(t *testing.T) {
+	table := []struct {
+		name   string
+		preIf  string
+		runPre bool
+	}{
+		{"pre-if-default", "always()", true},
+		{"pre-if-expression", "runner.os != 'Windows'", true},
+		{"pre-if-false", "false", false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			cm := &containerMock{}
+			sarm := &stepActionRemoteMocks{}
+
+			sar := &stepActionRemote{
+				Step: &model.Step{
+					ID:   "step",
+					Uses: "remote/action@v1",
+				},
+				RunContext: &RunContext{
+					Config: &Config{
+						GitHubInstance: "https://github.com",
+					},
+					JobContainer: cm,
+					Run: &model.Run{
+						JobID: "1",
+						Workflow: &model.Workflow{
+							Jobs: map[string]*model.Job{
+								"1": {},
+							},
+						},
+					},
+					StepResults: map[string]*model.StepResult{},
+				},
+				remoteAction: newRemoteAction("remote/action@v1"),
+				action: &model.Action{
+					Runs: model.ActionRuns{
+						Using: "node20",
+						Pre:   "pre.js",
+						PreIf: tt.preIf,
+						Main:  "main.js",
+					},
+				},
+				runAction: sarm.runAction,
+			}
+			sar.RunContext.ExprEval = sar.RunContext.NewExpressionEvaluator(ctx)
+
+			cm.On("Copy", "/var/run/act", mock.AnythingOfType("[]*container.FileEntry")).Return(func(ctx context.Context) error {
+				return nil
+			})
+			if tt.runPre {
+				cm.On("CopyDir", "/var/run/act/actions/remote-action@v1/", mock.Anything, mock.Anything).Return(func(ctx context.Context) error {
+					return nil
+				})
+				cm.On("Exec", []string{"node", "/var/run/act/actions/remote-action@v1/pre.js"}, mock.Anything, "", "").Return(func(ctx context.Context) error {
+					return nil
+				})
+				// the pre step writes to GITHUB_ENV
+				cm.On("UpdateFromEnv", "/var/run/act/workflow/envs.txt", mock.AnythingOfType("*map[string]string")).Run(func(args mock.Arguments) {
+					(*args.Get(1).(*map[string]string))["FROM_PRE"] = "pre-value"
+				}).Return(func(ctx context.Context) error {
+					return nil
+				}).Once()
+			}
+			cm.On("UpdateFromEnv", mock.AnythingOfType("string"), mock.AnythingOfType("*map[string]string")).Return(func(ctx context.Context) error {
+				return nil
+			})
+			cm.On("GetContainerArchive", ctx, "/var/run/act/workflow/pathcmd.txt").Return(io.NopCloser(&bytes.Buffer{}), nil)
+
+			var mainEnv map[string]string
+			sarm.On("runAction", sar, mock.AnythingOfType("string"), sar.remoteAction).Return(func(ctx context.Context) error {
+				mainEnv = sar.env
+				return nil
+			})
+
+			assert.NoError(t, sar.pre()(ctx))
+			assert.NoError(t, sar.main()(ctx))
+
+			if tt.runPre {
+				assert.Equal(t, "pre-value", mainEnv["FROM_PRE"])
+			} else {
+				assert.NotContains(t, mainEnv, "FROM_PRE")
+				cm.AssertNotCalled(t, "Exec", []string{"node", "/var/run/act/actions/remote-action@v1/pre.js"}, mock.Anything, "", "")
+			}
+			cm.AssertExpectations(t)
+			sarm.AssertExpectations(t)
+		})
+	}
+}
+
 func TestStepActionRemotePost(t *testing.T) {
 	table := []struct {
 		name               string
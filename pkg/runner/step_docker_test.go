@@ -103,6 +103,98 @@ func TestStepDockerMain(t *testing.T) {
 	cm.AssertExpectations(t)
 }
 
+func TestStepDockerPrivateImage(t *testing.T) {
+	const image = "ghcr.io/org/private@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	table := []struct {
+		name         string
+		jobContainer string
+		username     string
+		password     string
+	}{
+		{"JobContainerCredentials", "ghcr.io/org/job:1", "job-user", "job-password"},
+		{"OtherRegistry", "registry.example.com/job:1", "secret-user", "secret-password"},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &containerMock{}
+
+			var input *container.NewContainerInput
+			origContainerNewContainer := ContainerNewContainer
+			ContainerNewContainer = func(containerInput *container.NewContainerInput) container.ExecutionsEnvironment {
+				input = containerInput
+				return cm
+			}
+			defer (func() {
+				ContainerNewContainer = origContainerNewContainer
+			})()
+
+			job := &model.Job{}
+			assert.NoError(t, job.RawContainer.Encode(map[string]interface{}{
+				"image": tt.jobContainer,
+				"credentials": map[string]string{
+					"username": "job-user",
+					"password": "${{ secrets.JOB_PASSWORD }}",
+				},
+			}))
+
+			ctx := context.Background()
+			rc := &RunContext{
+				StepResults: map[string]*model.StepResult{},
+				Config: &Config{
+					Secrets: map[string]string{
+						"JOB_PASSWORD":    "job-password",
+						"DOCKER_USERNAME": "secret-user",
+						"DOCKER_PASSWORD": "secret-password",
+					},
+				},
+				Run: &model.Run{
+					JobID: "1",
+					Workflow: &model.Workflow{
+						Jobs: map[string]*model.Job{"1": job},
+					},
+				},
+				JobContainer: cm,
+			}
+			rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+			sd := &stepDocker{
+				RunContext: rc,
+				Step: &model.Step{
+					ID:   "1",
+					Uses: "docker://" + image,
+				},
+				env: map[string]string{},
+			}
+
+			cm.On("Pull", false).Return(func(ctx context.Context) error {
+				return nil
+			})
+			cm.On("Remove").Return(func(ctx context.Context) error {
+				return nil
+			})
+			cm.On("Create", []string(nil), []string(nil)).Return(func(ctx context.Context) error {
+				return nil
+			})
+			cm.On("Start", true).Return(func(ctx context.Context) error {
+				return nil
+			})
+			cm.On("Close").Return(func(ctx context.Context) error {
+				return nil
+			})
+
+			err := sd.runUsesContainer()(ctx)
+			assert.Nil(t, err)
+
+			assert.Equal(t, image, input.Image)
+			assert.Equal(t, tt.username, input.Username)
+			assert.Equal(t, tt.password, input.Password)
+
+			cm.AssertExpectations(t)
+		})
+	}
+}
+
 func TestStepDockerPrePost(t *testing.T) {
 	ctx := context.Background()
 	sd := &stepDocker{}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/kballard/go-shellquote"
@@ -76,8 +77,12 @@ func (sr *stepRun) setupShellCommandExecutor() common.Executor {
 			return err
 		}
 
+		if common.Dryrun(ctx) {
+			common.Logger(ctx).Infof("Resolved command: %s", sr.cmdline)
+		}
+
 		rc := sr.getRunContext()
-		return rc.JobContainer.Copy(rc.JobContainer.GetActPath(), &container.FileEntry{
+		return rc.copyToJobContainer(rc.JobContainer.GetActPath(), &container.FileEntry{
 			Name: scriptName,
 			Mode: 0o755,
 			Body: script,
@@ -85,6 +90,39 @@ func (sr *stepRun) setupShellCommandExecutor() common.Executor {
 	}
 }
 
+// ResolvedCommand returns the tokenized command and the script body the run
+// step would execute in this job, without copying the script into the container
+func (rc *RunContext) ResolvedCommand(ctx context.Context, step *model.Step) ([]string, string, error) {
+	if step.Type() != model.StepTypeRun {
+		return nil, "", fmt.Errorf("step %s is not a run step", step)
+	}
+	sr := &stepRun{
+		Step:       step,
+		RunContext: rc,
+	}
+	return sr.resolvedCommand(ctx)
+}
+
+func (sr *stepRun) resolvedCommand(ctx context.Context) ([]string, string, error) {
+	_, script, err := sr.setupShellCommand(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return sr.cmd, script, nil
+}
+
+// defaultStepID returns the id of the i-th step of a job or composite action if
+// the step has none. It is the index of the step, prefixed with underscores while
+// another step uses it as its id, so that results and script files don't collide.
+func defaultStepID(stepIDs map[string]bool, i int) string {
+	id := strconv.Itoa(i)
+	for stepIDs[id] {
+		id = "_" + id
+	}
+	stepIDs[id] = true
+	return id
+}
+
 func getScriptName(rc *RunContext, step *model.Step) string {
 	scriptName := step.ID
 	for rcs := rc; rcs.Parent != nil; rcs = rcs.Parent {
@@ -100,7 +138,9 @@ func getScriptName(rc *RunContext, step *model.Step) string {
 // OCI runtime exec failed: exec failed: container_linux.go:380: starting container process caused: exec: "${{": executable file not found in $PATH: unknown
 func (sr *stepRun) setupShellCommand(ctx context.Context) (name, script string, err error) {
 	logger := common.Logger(ctx)
-	sr.setupShell(ctx)
+	if err := sr.setupShell(ctx); err != nil {
+		return "", "", err
+	}
 	sr.setupWorkingDirectory(ctx)
 
 	step := sr.Step
@@ -108,6 +148,13 @@ func (sr *stepRun) setupShellCommand(ctx context.Context) (name, script string,
 	script = sr.RunContext.NewStepExpressionEvaluator(ctx, sr).Interpolate(ctx, step.Run)
 
 	scCmd := step.ShellCommand()
+	if sr.RunContext.Config.NonStrictBash && step.Shell == "bash" {
+		scCmd = "bash --noprofile --norc {0}"
+	}
+	if interpreter, ok := sr.RunContext.Config.ShellInterpreters[step.Shell]; ok && interpreter != "" && strings.HasPrefix(scCmd, step.Shell+" ") {
+		// only builtin shells start with their name, custom shells already name their interpreter
+		scCmd = interpreter + strings.TrimPrefix(scCmd, step.Shell)
+	}
 
 	name = getScriptName(sr.RunContext, step)
 
@@ -161,7 +208,9 @@ func (l *localEnv) Getenv(name string) string {
 	return l.env[name]
 }
 
-func (sr *stepRun) setupShell(ctx context.Context) {
+// setupShell resolves the shell of the step from its expression and the defaults,
+// a shell which resolves to an unsupported value is an error
+func (sr *stepRun) setupShell(ctx context.Context) error {
 	rc := sr.RunContext
 	step := sr.Step
 
@@ -169,7 +218,13 @@ func (sr *stepRun) setupShell(ctx context.Context) {
 		step.Shell = rc.Run.Job().Defaults.Run.Shell
 	}
 
+	rawShell := step.Shell
 	step.Shell = rc.NewExpressionEvaluator(ctx).Interpolate(ctx, step.Shell)
+	if step.Shell != rawShell {
+		if err := step.Validate(); err != nil {
+			return fmt.Errorf("shell '%s' of step '%s' resolved to an invalid value: %w", rawShell, step.String(), err)
+		}
+	}
 
 	if step.Shell == "" {
 		step.Shell = rc.Run.Workflow.Defaults.Run.Shell
@@ -197,6 +252,63 @@ func (sr *stepRun) setupShell(ctx context.Context) {
 			step.Shell = "sh"
 		}
 	}
+
+	sr.setupPowerShell(ctx)
+	return nil
+}
+
+// setupPowerShell falls back to the other PowerShell flavour if the requested one
+// isn't installed, e.g. images only shipping `powershell` but no `pwsh`
+func (sr *stepRun) setupPowerShell(ctx context.Context) {
+	step := sr.Step
+
+	var fallback string
+	switch step.Shell {
+	case "pwsh":
+		fallback = "powershell"
+	case "powershell":
+		fallback = "pwsh"
+	default:
+		return
+	}
+
+	if sr.hasShell(ctx, step.Shell) || !sr.hasShell(ctx, fallback) {
+		return
+	}
+	common.Logger(ctx).Warnf("'%s' is not available, falling back to '%s'", step.Shell, fallback)
+	step.Shell = fallback
+}
+
+// hasShell probes whether the shell executable exists in the job container,
+// the result is cached for all steps running in the same container
+func (sr *stepRun) hasShell(ctx context.Context, shell string) bool {
+	rc := sr.getRunContext()
+	for rc.Parent != nil {
+		rc = rc.Parent
+	}
+	if found, ok := rc.shellProbes[shell]; ok {
+		return found
+	}
+
+	env := map[string]string{}
+	for k, v := range sr.env {
+		env[k] = v
+	}
+	sr.getRunContext().ApplyExtraPath(ctx, &env)
+
+	var found bool
+	if _, ok := sr.getRunContext().JobContainer.(*container.HostEnvironment); ok {
+		_, err := lookpath.LookPath2(shell, &localEnv{env: env})
+		found = err == nil
+	} else {
+		found = sr.getRunContext().JobContainer.Exec([]string{"sh", "-c", fmt.Sprintf("command -v %s >/dev/null", shell)}, env, "", "")(ctx) == nil
+	}
+
+	if rc.shellProbes == nil {
+		rc.shellProbes = map[string]bool{}
+	}
+	rc.shellProbes[shell] = found
+	return found
 }
 
 func (sr *stepRun) setupWorkingDirectory(ctx context.Context) {
@@ -3,12 +3,15 @@ package runner
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/nektos/act/pkg/common"
 	"github.com/nektos/act/pkg/container"
 	"github.com/nektos/act/pkg/model"
 )
@@ -92,3 +95,299 @@ func TestStepRunPrePost(t *testing.T) {
 	err = sr.post()(ctx)
 	assert.Nil(t, err)
 }
+
+func TestStepRunResolvedCommand(t *testing.T) {
+	cm := &containerMock{}
+
+	rc := &RunContext{
+		StepResults: map[string]*model.StepResult{},
+		ExprEval:    &expressionEvaluator{},
+		Config:      &Config{},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {},
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+
+	cmd, script, err := rc.ResolvedCommand(context.Background(), &model.Step{
+		ID:    "1",
+		Run:   "echo 'hello world'",
+		Shell: "bash",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bash", "--noprofile", "--norc", "-e", "-o", "pipefail", "/var/run/act/workflow/1.sh"}, cmd)
+	assert.Equal(t, "\necho 'hello world'\n", script)
+
+	_, _, err = rc.ResolvedCommand(context.Background(), &model.Step{ID: "2", Uses: "docker://alpine"})
+	assert.Error(t, err)
+
+	cm.AssertNotCalled(t, "Copy", mock.Anything, mock.Anything)
+}
+
+func TestStepRunPowerShellFallback(t *testing.T) {
+	cm := &containerMock{}
+
+	rc := &RunContext{
+		StepResults: map[string]*model.StepResult{},
+		ExprEval:    &expressionEvaluator{},
+		Config:      &Config{},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {},
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+
+	cm.On("Exec", []string{"sh", "-c", "command -v pwsh >/dev/null"}, mock.AnythingOfType("map[string]string"), "", "").Return(func(ctx context.Context) error {
+		return errors.New("exit code 127")
+	})
+	cm.On("Exec", []string{"sh", "-c", "command -v powershell >/dev/null"}, mock.AnythingOfType("map[string]string"), "", "").Return(func(ctx context.Context) error {
+		return nil
+	})
+
+	for _, id := range []string{"1", "2"} {
+		sr := &stepRun{
+			RunContext: rc,
+			Step: &model.Step{
+				ID:    id,
+				Run:   "Write-Output hello",
+				Shell: "pwsh",
+			},
+		}
+
+		cmd, _, err := sr.resolvedCommand(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"powershell", "-command", ".", "/var/run/act/workflow/" + id + ".ps1"}, cmd)
+	}
+
+	// the probe results are cached for the job container
+	cm.AssertNumberOfCalls(t, "Exec", 2)
+}
+
+func TestStepRunCompositeDefaults(t *testing.T) {
+	cm := &containerMock{}
+
+	parent := &RunContext{
+		StepResults: map[string]*model.StepResult{},
+		Config:      &Config{},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Defaults: model.Defaults{Run: model.RunDefaults{Shell: "sh"}},
+				Jobs: map[string]*model.Job{
+					"1": {
+						Defaults: model.Defaults{Run: model.RunDefaults{Shell: "sh"}},
+					},
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+	parent.ExprEval = parent.NewExpressionEvaluator(context.Background())
+
+	sal := &stepActionLocal{
+		Step:       &model.Step{ID: "composite", Uses: "./action"},
+		RunContext: parent,
+		env:        map[string]string{},
+		action: &model.Action{
+			Defaults: model.Defaults{Run: model.RunDefaults{Shell: "bash"}},
+			Runs:     model.ActionRuns{Using: model.ActionRunsUsingComposite},
+		},
+	}
+
+	compositeRC := newCompositeRunContext(context.Background(), parent, sal, "/var/run/act/actions/action")
+
+	sr := &stepRun{
+		RunContext: compositeRC,
+		Step: &model.Step{
+			ID:  "1",
+			Run: "echo 'hello world'",
+		},
+	}
+
+	cmd, _, err := sr.resolvedCommand(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bash", "--noprofile", "--norc", "-e", "-o", "pipefail", "/var/run/act/workflow/-composite-1.sh"}, cmd)
+}
+
+func TestGetScriptNameUnnamedSteps(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo first
+    - run: echo second
+    - id: "0"
+      run: echo explicit
+`))
+	assert.NoError(t, err)
+
+	recorder := &container.RecordingEnvironment{}
+	rc := &RunContext{
+		Config: &Config{
+			Workdir:        "/work",
+			ActionCacheDir: t.TempDir(),
+			Platforms: map[string]string{
+				"ubuntu-latest": "node:16-buster-slim",
+			},
+			JobEnvironment: recorder,
+		},
+		Run:         &model.Run{JobID: "build", Workflow: workflow},
+		StepResults: map[string]*model.StepResult{},
+	}
+	ctx := common.WithJobErrorContainer(context.Background())
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+
+	assert.NoError(t, newJobExecutor(rc, &stepFactoryImpl{}, rc)(ctx))
+	assert.NoError(t, common.JobError(ctx))
+
+	assert.Contains(t, rc.StepResults, "_0")
+	assert.Contains(t, rc.StepResults, "1")
+	assert.Contains(t, rc.StepResults, "0")
+	// the steps of the job are shared by the legs of a matrix and left as they are
+	assert.Equal(t, "", workflow.GetJob("build").Steps[0].ID)
+	files := recorder.Files()
+	assert.Contains(t, files["/var/run/act/workflow/_0"], "echo first")
+	assert.Contains(t, files["/var/run/act/workflow/1"], "echo second")
+	assert.Contains(t, files["/var/run/act/workflow/0"], "echo explicit")
+}
+
+func TestStepRunShellInterpreters(t *testing.T) {
+	rc := &RunContext{
+		StepResults: map[string]*model.StepResult{},
+		ExprEval:    &expressionEvaluator{},
+		Config: &Config{
+			ShellInterpreters: map[string]string{
+				"bash": "/usr/local/bin/bash",
+				"node": "/opt/node/bin/node",
+			},
+		},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {},
+				},
+			},
+		},
+		JobContainer: &containerMock{},
+	}
+
+	for _, tt := range []struct {
+		id    string
+		shell string
+		cmd   []string
+	}{
+		{"bash", "bash", []string{"/usr/local/bin/bash", "--noprofile", "--norc", "-e", "-o", "pipefail", "/var/run/act/workflow/bash.sh"}},
+		{"sh", "sh", []string{"sh", "-e", "/var/run/act/workflow/sh.sh"}},
+		// custom shells name their interpreter themselves
+		{"node", "node {0}", []string{"node", "/var/run/act/workflow/node"}},
+	} {
+		t.Run(tt.id, func(t *testing.T) {
+			sr := &stepRun{
+				RunContext: rc,
+				Step: &model.Step{
+					ID:    tt.id,
+					Run:   "echo hello",
+					Shell: tt.shell,
+				},
+			}
+
+			cmd, _, err := sr.resolvedCommand(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, tt.cmd, cmd)
+		})
+	}
+}
+
+func TestStepRunNonStrictBash(t *testing.T) {
+	rc := &RunContext{
+		StepResults: map[string]*model.StepResult{},
+		ExprEval:    &expressionEvaluator{},
+		Config:      &Config{},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {},
+				},
+			},
+		},
+		JobContainer: &containerMock{},
+	}
+	resolve := func(shell string) []string {
+		sr := &stepRun{
+			RunContext: rc,
+			Step: &model.Step{
+				ID:    "step",
+				Run:   "false | true",
+				Shell: shell,
+			},
+		}
+		cmd, _, err := sr.resolvedCommand(context.Background())
+		assert.Nil(t, err)
+		return cmd
+	}
+
+	assert.Equal(t, []string{"bash", "--noprofile", "--norc", "-e", "-o", "pipefail", "/var/run/act/workflow/step.sh"}, resolve("bash"))
+
+	rc.Config.NonStrictBash = true
+	assert.Equal(t, []string{"bash", "--noprofile", "--norc", "/var/run/act/workflow/step.sh"}, resolve("bash"))
+	// explicit bash templates and other shells are kept
+	assert.Equal(t, []string{"bash", "-e", "/var/run/act/workflow/step"}, resolve("bash -e {0}"))
+	assert.Equal(t, []string{"sh", "-e", "/var/run/act/workflow/step.sh"}, resolve("sh"))
+
+	rc.Config.ShellInterpreters = map[string]string{"bash": "/usr/local/bin/bash"}
+	assert.Equal(t, []string{"/usr/local/bin/bash", "--noprofile", "--norc", "/var/run/act/workflow/step.sh"}, resolve("bash"))
+}
+
+func TestStepRunMatrixShellValidation(t *testing.T) {
+	// the mock has no expectations, executing anything in the container panics
+	cm := &containerMock{}
+	rc := &RunContext{
+		StepResults: map[string]*model.StepResult{},
+		Config:      &Config{},
+		Matrix:      map[string]interface{}{"shell": "fish"},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {},
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+	rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+	resolve := func(shell string) ([]string, error) {
+		sr := &stepRun{
+			RunContext: rc,
+			Step: &model.Step{
+				ID:    "step",
+				Name:  "greet",
+				Run:   "echo hello",
+				Shell: shell,
+			},
+		}
+		cmd, _, err := sr.resolvedCommand(context.Background())
+		return cmd, err
+	}
+
+	_, err := resolve("${{ matrix.shell }}")
+	assert.EqualError(t, err, "shell '${{ matrix.shell }}' of step 'greet' resolved to an invalid value: unsupported shell 'fish', use one of bash, pwsh, python, sh, cmd, powershell or a custom shell containing '{0}'")
+
+	rc.Matrix["shell"] = "bash"
+	cmd, err := resolve("${{ matrix.shell }}")
+	assert.NoError(t, err)
+	assert.Equal(t, "bash", cmd[0])
+}
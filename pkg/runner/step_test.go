@@ -1,10 +1,18 @@
 package runner
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
 	"github.com/nektos/act/pkg/model"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -192,6 +200,140 @@ func TestSetupEnv(t *testing.T) {
 	cm.AssertExpectations(t)
 }
 
+func TestSetupEnvInterpolatesContainerEnv(t *testing.T) {
+	var rawContainer yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte("image: node:16\nenv:\n  REF: ${{ github.sha }}\n  TOKEN: ${{ secrets.TOKEN }}\n"), &rawContainer))
+
+	sm := &stepMock{}
+	rc := &RunContext{
+		Config: &Config{
+			Env: map[string]string{
+				"SHA_REF": "abc123",
+			},
+			Secrets: map[string]string{
+				"TOKEN": "s3cr3t",
+			},
+		},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {
+						RawContainer: *rawContainer.Content[0],
+					},
+				},
+			},
+		},
+		JobContainer: &containerMock{},
+	}
+	env := map[string]string{}
+
+	sm.On("getRunContext").Return(rc)
+	sm.On("getGithubContext").Return(rc)
+	sm.On("getStepModel").Return(&model.Step{})
+	sm.On("getEnv").Return(&env)
+
+	err := setupEnv(context.Background(), sm)
+	assert.Nil(t, err)
+	assert.Equal(t, "abc123", env["REF"])
+	assert.Equal(t, "s3cr3t", env["TOKEN"])
+}
+
+func TestSetupEnvWorkflowEnv(t *testing.T) {
+	var jobEnv, stepEnv yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte("JOB_OVERRIDE: job-value\n"), &jobEnv))
+	assert.NoError(t, yaml.Unmarshal([]byte("WORKFLOW_OVERRIDE: step-value\nFROM_ENV_CONTEXT: ${{ env.WORKFLOW_KEY }}\n"), &stepEnv))
+
+	sm := &stepMock{}
+	rc := &RunContext{
+		Config: &Config{
+			Env: map[string]string{
+				"SHA_REF": "abc123",
+			},
+		},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Env: map[string]string{
+					"WORKFLOW_KEY":      "workflow-value",
+					"WORKFLOW_OVERRIDE": "workflow-value",
+					"JOB_OVERRIDE":      "workflow-value",
+				},
+				Jobs: map[string]*model.Job{
+					"1": {
+						Env: *jobEnv.Content[0],
+					},
+				},
+			},
+		},
+		JobContainer: &containerMock{},
+	}
+	step := &model.Step{
+		Env: *stepEnv.Content[0],
+	}
+	env := map[string]string{}
+
+	sm.On("getRunContext").Return(rc)
+	sm.On("getGithubContext").Return(rc)
+	sm.On("getStepModel").Return(step)
+	sm.On("getEnv").Return(&env)
+
+	err := setupEnv(context.Background(), sm)
+	assert.Nil(t, err)
+	assert.Equal(t, "workflow-value", env["WORKFLOW_KEY"])
+	assert.Equal(t, "step-value", env["WORKFLOW_OVERRIDE"])
+	assert.Equal(t, "job-value", env["JOB_OVERRIDE"])
+	assert.Equal(t, "workflow-value", env["FROM_ENV_CONTEXT"])
+
+	exprEval := rc.NewExpressionEvaluator(context.Background())
+	assert.Equal(t, "workflow-value", exprEval.Interpolate(context.Background(), "${{ env.WORKFLOW_KEY }}"))
+}
+
+func TestSetupEnvStepEnvOverridesGithubEnv(t *testing.T) {
+	var stepEnv yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte("SHADOWED: step-value\n"), &stepEnv))
+
+	rc := &RunContext{
+		Config: &Config{
+			Env: map[string]string{
+				"SHA_REF": "abc123",
+			},
+		},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {},
+				},
+			},
+		},
+		JobContainer: &containerMock{},
+	}
+	// set by a prior step via GITHUB_ENV
+	rc.setEnv(context.Background(), map[string]string{"name": "SHADOWED"}, "github-env-value")
+	rc.setEnv(context.Background(), map[string]string{"name": "PERSISTED"}, "github-env-value")
+
+	setup := func(step *model.Step) map[string]string {
+		sm := &stepMock{}
+		env := map[string]string{}
+		sm.On("getRunContext").Return(rc)
+		sm.On("getGithubContext").Return(rc)
+		sm.On("getStepModel").Return(step)
+		sm.On("getEnv").Return(&env)
+		assert.NoError(t, setupEnv(context.Background(), sm))
+		return env
+	}
+
+	env := setup(&model.Step{Env: *stepEnv.Content[0]})
+	assert.Equal(t, "step-value", env["SHADOWED"])
+	assert.Equal(t, "github-env-value", env["PERSISTED"])
+
+	// the shadowing is limited to the step declaring the env
+	env = setup(&model.Step{})
+	assert.Equal(t, "github-env-value", env["SHADOWED"])
+	assert.Equal(t, "github-env-value", env["PERSISTED"])
+}
+
 func TestIsStepEnabled(t *testing.T) {
 	createTestStep := func(t *testing.T, input string) step {
 		var step *model.Step
@@ -343,3 +485,436 @@ func TestIsContinueOnError(t *testing.T) {
 	assertObject.False(continueOnError)
 	assertObject.NotNil(err)
 }
+
+func TestValidateEnvFile(t *testing.T) {
+	table := []struct {
+		name      string
+		env       map[string]string
+		sizeLimit int
+		err       string
+	}{
+		{
+			name: "valid",
+			env:  map[string]string{"KEY": "value", "_key2": "value"},
+		},
+		{
+			name: "starts-with-digit",
+			env:  map[string]string{"1KEY": "value"},
+			err:  "invalid environment variable name '1KEY' in GITHUB_ENV: names must start with a letter or '_' and contain only alphanumeric characters or '_'",
+		},
+		{
+			name: "contains-equals",
+			env:  map[string]string{"KEY=": "value"},
+			err:  "invalid environment variable name 'KEY=' in GITHUB_ENV: names must start with a letter or '_' and contain only alphanumeric characters or '_'",
+		},
+		{
+			name: "reserved",
+			env:  map[string]string{"GITHUB_SHA": "value"},
+			err:  "environment variable 'GITHUB_SHA' can't be set via GITHUB_ENV",
+		},
+		{
+			name: "reserved-node-options",
+			env:  map[string]string{"node_options": "value"},
+			err:  "environment variable 'node_options' can't be set via GITHUB_ENV",
+		},
+		{
+			name: "custom-github-prefix",
+			env:  map[string]string{"GITHUB_SHA_SHORT": "value"},
+		},
+		{
+			name:      "oversized",
+			env:       map[string]string{"KEY": "0123456789"},
+			sizeLimit: 10,
+			err:       "GITHUB_ENV exceeds the size limit of 10 bytes (13 bytes)",
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &RunContext{Config: &Config{}, Run: &model.Run{JobID: "job", Workflow: &model.Workflow{Jobs: map[string]*model.Job{"job": {}}}}}
+			err := validateEnvFile(tt.env, tt.sizeLimit, reservedEnvNames(context.Background(), rc))
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+// windowsContainerMock is a job container with a windows act path
+type windowsContainerMock struct {
+	*containerMock
+}
+
+func (*windowsContainerMock) GetActPath() string {
+	return `C:\act`
+}
+
+func (*windowsContainerMock) IsEnvironmentCaseInsensitive() bool {
+	return true
+}
+
+func TestProcessRunnerEnvFileCommandWindowsPath(t *testing.T) {
+	linux := &RunContext{JobContainer: &containerMock{}}
+	assert.Equal(t, "/var/run/act/workflow/envs.txt", linux.joinActPath("/var/run/act", "workflow/envs.txt"))
+
+	windows := &RunContext{JobContainer: &windowsContainerMock{&containerMock{}}}
+	for actPath, want := range map[string]string{
+		`C:\act`:  `C:\act\workflow\envs.txt`,
+		`C:\act\`: `C:\act\workflow\envs.txt`,
+		"C:/act":  `C:\act\workflow\envs.txt`,
+	} {
+		assert.Equal(t, want, windows.joinActPath(actPath, "workflow/envs.txt"), actPath)
+	}
+
+	cm := &containerMock{}
+	cm.On("UpdateFromEnv", `C:\act\workflow\envs.txt`, mock.AnythingOfType("*map[string]string")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	rc := &RunContext{
+		Config:       &Config{},
+		JobContainer: &windowsContainerMock{cm},
+	}
+
+	err := processRunnerEnvFileCommand(context.Background(), "workflow/envs.txt", rc, rc.setEnv, nil)
+	assert.NoError(t, err)
+	cm.AssertExpectations(t)
+}
+
+func TestRunStepExecutorRandomizedFileCommands(t *testing.T) {
+	cm := &containerMock{}
+	rc := &RunContext{
+		Config: &Config{
+			RandomizeFileCommands: true,
+		},
+		StepResults: map[string]*model.StepResult{},
+		ExprEval:    &expressionEvaluator{},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {},
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+
+	ctx := context.Background()
+	cm.On("Copy", "/var/run/act", mock.AnythingOfType("[]*container.FileEntry")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("UpdateFromEnv", mock.AnythingOfType("string"), mock.AnythingOfType("*map[string]string")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("GetContainerArchive", ctx, mock.AnythingOfType("string")).Return(io.NopCloser(&bytes.Buffer{}), nil)
+
+	fileCommands := []string{"GITHUB_OUTPUT", "GITHUB_STATE", "GITHUB_PATH", "GITHUB_ENV", "GITHUB_STEP_SUMMARY"}
+	paths := map[string]string{}
+	for _, id := range []string{"1", "2"} {
+		sr := &stepRun{
+			RunContext: rc,
+			Step: &model.Step{
+				ID:  id,
+				Run: "cmd",
+			},
+			env: map[string]string{},
+		}
+		err := runStepExecutor(sr, stepStageMain, func(ctx context.Context) error {
+			return nil
+		})(ctx)
+		assert.NoError(t, err)
+
+		for _, name := range fileCommands {
+			p := sr.env[name]
+			assert.True(t, strings.HasPrefix(p, "/var/run/act/workflow/"), p)
+			assert.NotContains(t, paths, p, "file command %s of step %s is not unique", name, id)
+			paths[p] = name
+		}
+	}
+	assert.Len(t, paths, 2*len(fileCommands))
+}
+
+func TestRunStepExecutorCopiedFiles(t *testing.T) {
+	cm := &containerMock{}
+	rc := &RunContext{
+		Config:      &Config{},
+		StepResults: map[string]*model.StepResult{},
+		ExprEval:    &expressionEvaluator{},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {},
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+
+	ctx := context.Background()
+	cm.On("Copy", "/var/run/act", mock.AnythingOfType("[]*container.FileEntry")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("UpdateFromEnv", mock.AnythingOfType("string"), mock.AnythingOfType("*map[string]string")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("GetContainerArchive", ctx, mock.AnythingOfType("string")).Return(io.NopCloser(&bytes.Buffer{}), nil)
+
+	sr := &stepRun{
+		RunContext: rc,
+		Step: &model.Step{
+			ID:    "1",
+			Run:   "cmd",
+			Shell: "bash",
+		},
+		env: map[string]string{},
+	}
+	assert.Empty(t, rc.CopiedFiles())
+	err := runStepExecutor(sr, stepStageMain, sr.setupShellCommandExecutor())(ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []container.FileEntry{
+		{Name: "/var/run/act/workflow/outputcmd.txt", Mode: 0o666},
+		{Name: "/var/run/act/workflow/statecmd.txt", Mode: 0o666},
+		{Name: "/var/run/act/workflow/pathcmd.txt", Mode: 0o666},
+		{Name: "/var/run/act/workflow/envs.txt", Mode: 0o666},
+		{Name: "/var/run/act/workflow/SUMMARY.md", Mode: 0o666},
+		{Name: "/var/run/act/workflow/1.sh", Mode: 0o755},
+	}, rc.CopiedFiles())
+
+	// composite actions record into the RunContext of the job
+	compositeRC := &RunContext{Parent: rc, JobContainer: cm}
+	assert.NoError(t, compositeRC.copyToJobContainer("/var/run/act", &container.FileEntry{Name: "workflow/1-composite-0.sh", Mode: 0o755})(ctx))
+	assert.Len(t, rc.CopiedFiles(), 7)
+	assert.Equal(t, rc.CopiedFiles(), compositeRC.CopiedFiles())
+}
+
+func TestRunStepExecutorStrictWorkflowCommands(t *testing.T) {
+	cm := &containerMock{}
+	rc := &RunContext{
+		Config: &Config{
+			StrictWorkflowCommands: true,
+		},
+		StepResults: map[string]*model.StepResult{},
+		ExprEval:    &expressionEvaluator{},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {},
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+
+	ctx := context.Background()
+	cm.On("Copy", "/var/run/act", mock.AnythingOfType("[]*container.FileEntry")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("UpdateFromEnv", mock.AnythingOfType("string"), mock.AnythingOfType("*map[string]string")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("GetContainerArchive", ctx, mock.AnythingOfType("string")).Return(io.NopCloser(&bytes.Buffer{}), nil)
+
+	for _, tt := range []struct {
+		id     string
+		output string
+		err    string
+	}{
+		{"malformed", "::error file=::broken annotation\n", "invalid workflow command '::error file=::broken annotation': malformed property 'file='"},
+		{"valid", "::error file=main.go::broken build\n", ""},
+	} {
+		// a failed step would skip the following steps
+		rc.StepResults = map[string]*model.StepResult{}
+		sr := &stepRun{
+			RunContext: rc,
+			Step: &model.Step{
+				ID:  tt.id,
+				Run: "cmd",
+			},
+			env: map[string]string{},
+		}
+		err := runStepExecutor(sr, stepStageMain, func(ctx context.Context) error {
+			_, err := rc.newLogWriter(ctx).Write([]byte(tt.output))
+			return err
+		})(ctx)
+
+		if tt.err == "" {
+			assert.NoError(t, err)
+			assert.Equal(t, model.StepStatusSuccess, rc.StepResults[tt.id].Conclusion)
+		} else {
+			assert.EqualError(t, err, tt.err)
+			assert.Equal(t, model.StepStatusFailure, rc.StepResults[tt.id].Conclusion)
+		}
+	}
+}
+
+func TestRunStepExecutorDuration(t *testing.T) {
+	cm := &containerMock{}
+	rc := &RunContext{
+		Config:      &Config{},
+		StepResults: map[string]*model.StepResult{},
+		ExprEval:    &expressionEvaluator{},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": {},
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+
+	ctx := context.Background()
+	cm.On("Copy", "/var/run/act", mock.AnythingOfType("[]*container.FileEntry")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("UpdateFromEnv", mock.AnythingOfType("string"), mock.AnythingOfType("*map[string]string")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("GetContainerArchive", ctx, mock.AnythingOfType("string")).Return(io.NopCloser(&bytes.Buffer{}), nil)
+
+	for _, id := range []string{"first", "second"} {
+		sr := &stepRun{
+			RunContext: rc,
+			Step: &model.Step{
+				ID:  id,
+				Run: "cmd",
+			},
+			env: map[string]string{},
+		}
+		err := runStepExecutor(sr, stepStageMain, func(ctx context.Context) error {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		})(ctx)
+		assert.NoError(t, err)
+
+		duration := rc.StepResults[id].Duration
+		assert.GreaterOrEqual(t, duration, 50*time.Millisecond)
+		assert.Less(t, duration, 5*time.Second)
+	}
+
+	assert.Equal(t, rc.StepResults["first"].Duration+rc.StepResults["second"].Duration, rc.stepsDuration())
+}
+
+func TestRunStepExecutorContinueOnErrorOutcome(t *testing.T) {
+	cm := &containerMock{}
+	rc := &RunContext{
+		Config:      &Config{},
+		StepResults: map[string]*model.StepResult{},
+		Env:         map[string]string{},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": createJob(t, `runs-on: ubuntu-latest`, ""),
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+
+	ctx := context.Background()
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+	cm.On("Copy", "/var/run/act", mock.AnythingOfType("[]*container.FileEntry")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("UpdateFromEnv", mock.AnythingOfType("string"), mock.AnythingOfType("*map[string]string")).Return(func(ctx context.Context) error {
+		return nil
+	})
+	cm.On("GetContainerArchive", ctx, mock.AnythingOfType("string")).Return(io.NopCloser(&bytes.Buffer{}), nil)
+
+	run := func(input string, executor common.Executor) error {
+		var step *model.Step
+		assert.NoError(t, yaml.Unmarshal([]byte(input), &step))
+		sr := &stepRun{
+			RunContext: rc,
+			Step:       step,
+			env:        map[string]string{},
+		}
+		return runStepExecutor(sr, stepStageMain, executor)(ctx)
+	}
+
+	err := run(`
+id: flaky
+run: exit 1
+continue-on-error: true`, func(ctx context.Context) error {
+		return errors.New("exit status 1")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, model.StepStatusFailure, rc.StepResults["flaky"].Outcome)
+	assert.Equal(t, model.StepStatusSuccess, rc.StepResults["flaky"].Conclusion)
+
+	ran := map[string]bool{}
+	for id, cond := range map[string]string{
+		"outcome":    "steps.flaky.outcome == 'failure'",
+		"conclusion": "steps.flaky.conclusion == 'success'",
+		"failure":    "failure()",
+	} {
+		id := id
+		err = run(fmt.Sprintf("id: %s\nrun: echo\nif: %s", id, cond), func(ctx context.Context) error {
+			ran[id] = true
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+	// the job doesn't fail, so failure() is false
+	assert.Equal(t, map[string]bool{"outcome": true, "conclusion": true}, ran)
+}
+
+func TestSetupEnvExtraPath(t *testing.T) {
+	ctx := context.Background()
+	cm := &containerMock{}
+	rc := &RunContext{
+		Config:      &Config{},
+		StepResults: map[string]*model.StepResult{},
+		Env:         map[string]string{},
+		Run: &model.Run{
+			JobID: "1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"1": createJob(t, `runs-on: ubuntu-latest`, ""),
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+
+	// step A adds /opt/bin via GITHUB_PATH
+	pathTar := &bytes.Buffer{}
+	tw := tar.NewWriter(pathTar)
+	content := "/opt/bin\n"
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "pathcmd.txt", Mode: 0o666, Size: int64(len(content))}))
+	_, _ = tw.Write([]byte(content))
+	assert.NoError(t, tw.Close())
+	cm.On("GetContainerArchive", ctx, "/var/run/act/workflow/pathcmd.txt").Return(io.NopCloser(pathTar), nil)
+	assert.NoError(t, rc.UpdateExtraPath(ctx, "/var/run/act/workflow/pathcmd.txt"))
+
+	cm.On("UpdateFromImageEnv", mock.AnythingOfType("*map[string]string")).Run(func(args mock.Arguments) {
+		(*args.Get(0).(*map[string]string))["PATH"] = "/usr/bin:/bin"
+	}).Return(func(ctx context.Context) error {
+		return nil
+	})
+
+	// step B sees it at the front of env.PATH
+	sr := &stepRun{
+		RunContext: rc,
+		Step: &model.Step{
+			ID:  "b",
+			Run: "echo ${{ env.PATH }}",
+		},
+		env: map[string]string{},
+	}
+	assert.NoError(t, setupEnv(ctx, sr))
+	assert.Equal(t, "/opt/bin:/usr/bin:/bin", rc.NewStepExpressionEvaluator(ctx, sr).Interpolate(ctx, "${{ env.PATH }}"))
+
+	// the env of the step is left alone, the paths are applied once before it runs
+	assert.NotContains(t, sr.env, "PATH")
+	rc.ApplyExtraPath(ctx, &sr.env)
+	assert.Equal(t, "/opt/bin:/usr/bin:/bin", sr.env["PATH"])
+}